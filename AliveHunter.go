@@ -3,8 +3,9 @@ package main
 import (
     "bufio"
     "context"
+    "crypto/sha256"
     "crypto/tls"
-    "encoding/json"
+    "encoding/hex"
     "errors"
     "flag"
     "fmt"
@@ -24,6 +25,8 @@ import (
     "time"
 
     "github.com/fatih/color"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/trace"
     "golang.org/x/net/html"
     "golang.org/x/time/rate"
 )
@@ -36,6 +39,7 @@ const (
     BATCH_SIZE      = 1000
     MAX_BODY_SIZE   = 10 * 1024 // 10KB for verification
     TITLE_BODY_SIZE = 8192      // 8KB for title extraction
+    MATCH_BODY_SIZE = 1024 * 1024 // 1MB for body matchers
 )
 
 // Compile regex once for performance
@@ -61,6 +65,22 @@ type Config struct {
     ShowFailed    bool          // Show failed requests
     RobustTitle   bool          // Use robust HTML parser for titles (slower)
     TLSMinVersion uint16        // Minimum TLS version
+    ProbeStrategy string        // Which schemes to probe: respect, both, https, http, dual
+    SchemeAudit   bool          // Compare HTTP and HTTPS: upgrade redirect and HSTS
+    HTTP2         bool          // Negotiate HTTP/2 via ALPN
+    HTTP3         bool          // Probe HTTP/3 over QUIC
+    H2C           bool          // Detect cleartext HTTP/2 and Upgrade: h2c
+    BannerGrab    bool          // Grab banners from non-HTTP services
+    TableFormat   string        // Table output: csv, tsv or markdown
+    Columns       []string      // Result fields (JSON names) used as table columns
+    OutputTemplate string        // text/template used to render each result
+    DiffBaseline  string        // Previous scan to diff against: results file or scan ID
+    Watch         bool          // Re-scan on a schedule and only output changes
+    Trace         bool          // Trace targets and requests with OpenTelemetry
+    StoreResponse string        // Directory for raw responses, empty to disable
+    Headers       []string      // Response headers to include in results, or "all"
+    Match         ResponseMatchers // Only alive if every configured kind matches (-mr, -ms, -ml, ...)
+    Filter        ResponseMatchers // Not alive if any configured kind matches (-fr, -fs, -fl, ...)
 }
 
 // Result represents the outcome of checking a single URL
@@ -75,6 +95,21 @@ type Result struct {
     Error        string        `json:"error,omitempty"`
     Alive        bool          `json:"alive"`
     Verified     bool          `json:"verified"`
+    Meta         map[string]interface{} `json:"meta,omitempty"` // Pass-through input metadata
+    Schemes      *SchemeReport `json:"schemes,omitempty"`
+    Protocols    []string      `json:"protocols,omitempty"`     // Supported protocols: http/1.1, h2, h3
+    H3Advertised bool          `json:"h3_advertised,omitempty"` // Alt-Svc advertises h3
+    H2C          *H2CReport    `json:"h2c,omitempty"`
+    Service      string        `json:"service,omitempty"` // Non-HTTP service detected from the banner
+    Banner       string        `json:"banner,omitempty"`
+    SchemeMismatch string      `json:"scheme_mismatch,omitempty"` // Scheme did not match the port and was corrected
+    CertSHA256   string        `json:"cert_sha256,omitempty"`   // SHA-256 of the leaf TLS certificate
+    Diff         string        `json:"diff,omitempty"`          // Change against the -diff baseline: new, gone, changed
+    Changes      []string      `json:"changes,omitempty"`       // Fields that changed, e.g. "status_code: 200 -> 403"
+    StoredResponse string      `json:"stored_response,omitempty"` // File holding the raw response (-store-response)
+    Headers      map[string]string `json:"headers,omitempty"`       // Captured response headers (-headers)
+    Words        int           `json:"words,omitempty"`         // Word count of the body bytes read
+    Lines        int           `json:"lines,omitempty"`         // Line count of the body bytes read
 }
 
 // Stats tracks scanning progress and performance metrics
@@ -104,8 +139,12 @@ func (s *Stats) String() string {
 
 // AliveHTTPClient is an optimized HTTP client for maximum speed
 type AliveHTTPClient struct {
-    client    *http.Client
-    transport *http.Transport
+    client           *http.Client
+    noRedirectClient *http.Client // Never follows redirects, for scheme audits
+    h3client         *http.Client // QUIC client, only set when HTTP/3 probing is enabled
+    h2cclient        *http.Client // Prior-knowledge h2c client, only set when h2c checks are enabled
+    transport        *http.Transport
+    responses        *ResponseStore // Raw response storage, only set with -store-response
 }
 
 // NewAliveHTTPClient creates a new optimized HTTP client
@@ -125,7 +164,7 @@ func NewAliveHTTPClient(config *Config) *AliveHTTPClient {
         IdleConnTimeout:       0,
         DisableKeepAlives:     true,                 // Optimal for diverse host scanning
         DisableCompression:    true,                 // Less CPU overhead
-        ForceAttemptHTTP2:     false,                // HTTP/1.1 is faster for this use case
+        ForceAttemptHTTP2:     config.HTTP2,         // HTTP/1.1 is faster for this use case unless h2 is requested
         ExpectContinueTimeout: 0,
         ResponseHeaderTimeout: config.Timeout,
         
@@ -136,10 +175,26 @@ func NewAliveHTTPClient(config *Config) *AliveHTTPClient {
         },
     }
 
+    var h3client, h2cclient *http.Client
+    if config.HTTP3 {
+        h3client = newHTTP3Client(config)
+    }
+    if config.H2C {
+        h2cclient = newH2CClient(config)
+    }
+
+    // Request spans only when tracing, the wrapper is not free
+    var roundTripper http.RoundTripper = transport
+    if config.Trace {
+        roundTripper = tracedTransport(transport)
+    }
+
     return &AliveHTTPClient{
         transport: transport,
+        h3client:  h3client,
+        h2cclient: h2cclient,
         client: &http.Client{
-            Transport: transport,
+            Transport: roundTripper,
             Timeout:   config.Timeout,
             CheckRedirect: func(req *http.Request, via []*http.Request) error {
                 if !config.FollowRedirect || len(via) >= 3 {
@@ -148,6 +203,24 @@ func NewAliveHTTPClient(config *Config) *AliveHTTPClient {
                 return nil
             },
         },
+        noRedirectClient: &http.Client{
+            Transport: roundTripper,
+            Timeout:   config.Timeout,
+            CheckRedirect: func(req *http.Request, via []*http.Request) error {
+                return http.ErrUseLastResponse
+            },
+        },
+    }
+}
+
+// Close releases idle connections and the UDP socket of the HTTP/3 client
+func (ac *AliveHTTPClient) Close() {
+    ac.transport.CloseIdleConnections()
+    if ac.h2cclient != nil {
+        ac.h2cclient.CloseIdleConnections()
+    }
+    if ac.h3client != nil {
+        closeHTTP3Client(ac.h3client)
     }
 }
 
@@ -160,6 +233,15 @@ const (
     RequestTypeVerification
 )
 
+// Probe strategies deciding which schemes CheckURL tries
+const (
+    ProbeRespect = "respect" // Use the input's scheme if given, otherwise HTTPS then HTTP
+    ProbeBoth    = "both"    // Try the input's scheme (or HTTPS) first, fall back to the other
+    ProbeHTTPS   = "https"   // HTTPS only
+    ProbeHTTP    = "http"    // HTTP only
+    ProbeDual    = "dual"    // Probe both schemes and report both results
+)
+
 // createRequest creates a new HTTP request with appropriate headers for the request type
 func (ac *AliveHTTPClient) createRequest(ctx context.Context, method, url string, reqType RequestType) (*http.Request, error) {
     req, err := http.NewRequestWithContext(ctx, method, url, nil)
@@ -198,7 +280,6 @@ func (ac *AliveHTTPClient) fetchBody(ctx context.Context, fullURL string, reqTyp
 
 // CheckURL performs ultra-fast URL verification with minimal false positives
 func (ac *AliveHTTPClient) CheckURL(ctx context.Context, rawURL string, config *Config) *Result {
-    start := time.Now()
     result := &Result{URL: rawURL}
     
     // Robust URL validation
@@ -207,117 +288,198 @@ func (ac *AliveHTTPClient) CheckURL(ctx context.Context, rawURL string, config *
         return result
     }
 
-    // Try HTTPS first (more common in 2024), then HTTP
-    protocols := []string{"https://", "http://"}
+    // Probe each candidate URL in order, the first one that answers wins
     var lastError error
-    
-    for _, protocol := range protocols {
-        fullURL := protocol + strings.TrimPrefix(strings.TrimPrefix(rawURL, "https://"), "http://")
-        
-        // Use HEAD by default for speed, GET only if we need title
-        method := "HEAD"
-        if config.ExtractTitle {
-            method = "GET"
-        }
-        
-        req, err := ac.createRequest(ctx, method, fullURL, RequestTypeCheck)
+    for _, fullURL := range probeURLs(rawURL, config.ProbeStrategy) {
+        probed, err := ac.probeSchemeAware(ctx, fullURL, config)
         if err != nil {
             lastError = err
             continue
         }
-        
-        resp, err := ac.client.Do(req)
+        return probed
+    }
+    
+    // If we get here, every protocol failed
+    if lastError != nil {
+        result.Error = fmt.Sprintf("connection_failed: %s", lastError.Error())
+    } else {
+        result.Error = "no_response"
+    }
+    return result
+}
+
+// CheckURLDual probes both HTTPS and HTTP and returns one result per scheme
+func (ac *AliveHTTPClient) CheckURLDual(ctx context.Context, rawURL string, config *Config) []*Result {
+    if !isValidURL(rawURL) {
+        return []*Result{{URL: rawURL, Error: "invalid_url"}}
+    }
+
+    var results []*Result
+    for _, fullURL := range probeURLs(rawURL, ProbeDual) {
+        result, err := ac.probe(ctx, fullURL, config)
         if err != nil {
-            lastError = err
-            // In fast mode, don't retry
-            if config.FastMode {
-                continue
-            }
-            // In normal mode, one quick retry with exponential backoff
-            time.Sleep(50 * time.Millisecond)
-            resp, err = ac.client.Do(req)
-            if err != nil {
-                lastError = err
-                continue
-            }
+            result = &Result{URL: fullURL, Error: fmt.Sprintf("connection_failed: %s", err.Error())}
         }
-        
-        defer resp.Body.Close()
-        
-        // Populate basic result data
-        result.URL = fullURL
-        result.Status = resp.StatusCode
-        result.ResponseTime = time.Since(start)
-        result.Server = resp.Header.Get("Server")
-        
-        // Calculate content length carefully
-        if method == "GET" && resp.Body != nil {
-            // Consume body to get actual length, but save it for potential reuse
-            bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, config.MaxBodySize))
-            if err == nil {
-                result.Length = int64(len(bodyBytes))
-                
-                // Store body for potential title extraction or verification
-                resp.Body = io.NopCloser(strings.NewReader(string(bodyBytes)))
-            }
-        } else if resp.ContentLength > 0 {
-            result.Length = resp.ContentLength
+        results = append(results, result)
+    }
+    return results
+}
+
+// probe requests a single fully-qualified URL; an error means no response
+func (ac *AliveHTTPClient) probe(ctx context.Context, fullURL string, config *Config) (*Result, error) {
+    ctx, span := tracer.Start(ctx, "probe", trace.WithAttributes(attribute.String("url.full", fullURL)))
+    result, err := ac.probeURL(ctx, fullURL, config)
+    endProbeSpan(span, result, err)
+    return result, err
+}
+
+// probeURL does the requests for probe
+func (ac *AliveHTTPClient) probeURL(ctx context.Context, fullURL string, config *Config) (*Result, error) {
+    start := time.Now()
+    
+    // Use HEAD by default for speed, GET only if we need title or the body
+    method := "HEAD"
+    bodyMatchers := config.Match.needsBody() || config.Filter.needsBody()
+    if config.ExtractTitle || ac.responses != nil || bodyMatchers {
+        method = "GET"
+    }
+    
+    req, err := ac.createRequest(ctx, method, fullURL, RequestTypeCheck)
+    if err != nil {
+        return nil, err
+    }
+    
+    resp, err := ac.client.Do(req)
+    if err != nil {
+        // In fast mode, don't retry
+        if config.FastMode {
+            return nil, err
         }
-        
-        // Determine if URL is "alive" based on reliable status codes
-        if isAliveStatus(resp.StatusCode, config) {
-            result.Alive = true
+        // In normal mode, one quick retry with exponential backoff
+        trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(attribute.String("error", err.Error())))
+        time.Sleep(50 * time.Millisecond)
+        resp, err = ac.client.Do(req)
+        if err != nil {
+            return nil, err
+        }
+    }
+    
+    defer resp.Body.Close()
+    
+    // Populate basic result data
+    result := &Result{
+        URL:          fullURL,
+        Status:       resp.StatusCode,
+        ResponseTime: time.Since(start),
+        Server:       resp.Header.Get("Server"),
+        Headers:      captureHeaders(resp.Header, config.Headers),
+    }
+    
+    // Fingerprint the leaf certificate so certificate changes show up in diffs
+    if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+        sum := sha256.Sum256(resp.TLS.PeerCertificates[0].Raw)
+        result.CertSHA256 = hex.EncodeToString(sum[:])
+    }
+    
+    // Calculate content length carefully
+    var bodyBytes []byte
+    if method == "GET" && resp.Body != nil {
+        // Consume body to get actual length, but save it for potential reuse
+        limit := config.MaxBodySize
+        if bodyMatchers && limit < MATCH_BODY_SIZE {
+            limit = MATCH_BODY_SIZE
+        }
+        if ac.responses != nil && ac.responses.limit > limit {
+            limit = ac.responses.limit
+        }
+        var err error
+        bodyBytes, err = io.ReadAll(io.LimitReader(resp.Body, limit))
+        if err == nil {
+            result.Length = int64(len(bodyBytes))
+            countBody(result, bodyBytes)
             
-            // Additional verification to prevent false positives
-            needsVerification := !config.FastMode && shouldVerifyResponse(resp, config)
-            if needsVerification {
-                verified, verifyErr := ac.performVerification(ctx, fullURL, method == "GET", resp)
-                if verifyErr != nil {
-                    result.Error = fmt.Sprintf("verification_failed: %s", verifyErr.Error())
-                } else if !verified {
-                    result.Alive = false
-                    result.Error = "false_positive_detected"
-                    return result
+            // Keep the raw response on disk for offline analysis
+            if ac.responses != nil {
+                if path, err := ac.responses.Save(fullURL, resp, bodyBytes); err == nil {
+                    result.StoredResponse = path
                 } else {
-                    result.Verified = true
+                    fmt.Fprintf(os.Stderr, "Error storing response for %s: %v\n", fullURL, err)
                 }
             }
             
-            // Extract title if required
-            if config.ExtractTitle {
-                if method == "GET" && resp.Body != nil {
-                    // Use the already-read body
-                    result.Title = ac.extractTitle(resp.Body, config.RobustTitle)
+            // Store body for potential title extraction or verification
+            resp.Body = io.NopCloser(strings.NewReader(string(bodyBytes)))
+        }
+    } else if resp.ContentLength > 0 {
+        result.Length = resp.ContentLength
+    }
+    
+    // Record the negotiated protocol when protocol probing is enabled
+    if config.HTTP2 || config.HTTP3 {
+        result.Protocols = []string{alpnName(resp.Proto)}
+    }
+    
+    // Determine if URL is "alive" based on reliable status codes and the -m*/-f* matchers
+    if responseMatches(resp, bodyBytes, result, config) {
+        result.Alive = true
+        
+        // HTTP/3 via the Alt-Svc advertised endpoint or a direct QUIC attempt
+        if config.HTTP3 && strings.HasPrefix(fullURL, "https://") {
+            authority, advertised := altSvcH3Authority(resp.Header.Get("Alt-Svc"))
+            result.H3Advertised = advertised
+            if ac.probeHTTP3(ctx, fullURL, authority) {
+                result.Protocols = append(result.Protocols, ProtoH3)
+            }
+        }
+        
+        // Additional verification to prevent false positives
+        needsVerification := !config.FastMode && shouldVerifyResponse(resp, config)
+        if needsVerification {
+            verified, verifyErr := ac.performVerification(ctx, fullURL, method == "GET", resp)
+            if verifyErr != nil {
+                result.Error = fmt.Sprintf("verification_failed: %s", verifyErr.Error())
+            } else if !verified {
+                result.Alive = false
+                result.Error = "false_positive_detected"
+                return result, nil
+            } else {
+                result.Verified = true
+            }
+        }
+        
+        // Extract title if required
+        if config.ExtractTitle {
+            if method == "GET" && resp.Body != nil {
+                // Use the already-read body
+                result.Title = ac.extractTitle(resp.Body, config.RobustTitle)
+            } else {
+                // Make a GET request specifically for title
+                titleCtx, span := tracer.Start(ctx, "title")
+                titleResp, err := ac.fetchBody(titleCtx, fullURL, RequestTypeTitle)
+                if err == nil {
+                    defer titleResp.Body.Close()
+                    result.Title = ac.extractTitle(titleResp.Body, config.RobustTitle)
                 } else {
-                    // Make a GET request specifically for title
-                    titleResp, err := ac.fetchBody(ctx, fullURL, RequestTypeTitle)
-                    if err == nil {
-                        defer titleResp.Body.Close()
-                        result.Title = ac.extractTitle(titleResp.Body, config.RobustTitle)
-                    }
+                    span.RecordError(err)
                 }
-            }
-            
-            // Handle redirects
-            if isRedirect(resp.StatusCode) && resp.Header.Get("Location") != "" {
-                result.Redirect = resp.Header.Get("Location")
+                span.End()
             }
         }
         
-        return result
+        // Handle redirects
+        if isRedirect(resp.StatusCode) && resp.Header.Get("Location") != "" {
+            result.Redirect = resp.Header.Get("Location")
+        }
     }
     
-    // If we get here, both protocols failed
-    if lastError != nil {
-        result.Error = fmt.Sprintf("connection_failed: %s", lastError.Error())
-    } else {
-        result.Error = "no_response"
-    }
-    return result
+    return result, nil
 }
 
 // performVerification does additional verification to prevent false positives
 func (ac *AliveHTTPClient) performVerification(ctx context.Context, fullURL string, alreadyGET bool, originalResp *http.Response) (bool, error) {
+    ctx, span := tracer.Start(ctx, "verify", trace.WithAttributes(attribute.Bool("alivehunter.body_reused", alreadyGET)))
+    defer span.End()
+    
     var resp *http.Response
     var err error
     
@@ -423,19 +585,52 @@ func isAliveStatus(status int, config *Config) bool {
     return false
 }
 
+// responseMatches is the gate a response passes to count as alive: a live
+// status code and the -m*/-f* matchers
+func responseMatches(resp *http.Response, body []byte, result *Result, config *Config) bool {
+    return isAliveStatus(resp.StatusCode, config) && responseAllowed(resp.Header, body, result, config)
+}
+
 // isRedirect checks if status code indicates a redirect
 func isRedirect(status int) bool {
     return status >= 300 && status < 400
 }
 
+// probeURLs expands an input into the fully-qualified URLs to try, in order
+func probeURLs(rawURL, strategy string) []string {
+    scheme, rest := "", rawURL
+    if idx := strings.Index(rawURL, "://"); idx != -1 {
+        scheme, rest = strings.ToLower(rawURL[:idx]), rawURL[idx+3:]
+    }
+
+    switch strategy {
+    case ProbeHTTPS:
+        return []string{"https://" + rest}
+    case ProbeHTTP:
+        return []string{"http://" + rest}
+    case ProbeBoth, ProbeDual:
+        if scheme == "http" {
+            return []string{"http://" + rest, "https://" + rest}
+        }
+        return []string{"https://" + rest, "http://" + rest}
+    }
+
+    // Respect an explicit scheme, otherwise try HTTPS first (more common in 2024), then HTTP
+    if scheme == "http" || scheme == "https" {
+        return []string{scheme + "://" + rest}
+    }
+    return []string{"https://" + rest, "http://" + rest}
+}
+
 // isValidURL performs robust URL validation
 func isValidURL(rawURL string) bool {
     if rawURL == "" || len(rawURL) > 200 {
         return false
     }
     
-    // Quick basic validation first for performance
-    if strings.ContainsAny(rawURL, " \t\n\r<>\"{}|\\^`[]") {
+    // Quick basic validation first for performance; brackets are only
+    // allowed around an IPv6 host, as port scanner input produces them
+    if strings.ContainsAny(withoutIPv6Host(rawURL), " \t\n\r<>\"{}|\\^`[]") {
         return false
     }
     
@@ -450,6 +645,22 @@ func isValidURL(rawURL string) bool {
     return err == nil
 }
 
+// withoutIPv6Host removes a bracketed IPv6 host such as [2001:db8::1]
+func withoutIPv6Host(rawURL string) string {
+    start := 0
+    if idx := strings.Index(rawURL, "://"); idx != -1 {
+        start = idx + 3
+    }
+    if !strings.HasPrefix(rawURL[start:], "[") {
+        return rawURL
+    }
+    end := strings.Index(rawURL[start:], "]")
+    if end == -1 || net.ParseIP(rawURL[start+1:start+end]) == nil {
+        return rawURL
+    }
+    return rawURL[:start] + rawURL[start+end+1:]
+}
+
 // shouldVerifyResponse determines if additional verification is needed
 func shouldVerifyResponse(resp *http.Response, config *Config) bool {
     // In fast mode, skip verification
@@ -570,7 +781,7 @@ func (ac *AliveHTTPClient) extractTitleRobust(body io.Reader) string {
 }
 
 // processURLs is the main worker function that processes URLs from a channel
-func processURLs(ctx context.Context, urls <-chan string, results chan<- *Result, client *AliveHTTPClient, config *Config, stats *Stats, limiter *rate.Limiter) {
+func processURLs(ctx context.Context, urls <-chan Target, results chan<- *Result, client *AliveHTTPClient, config *Config, stats *Stats, limiter *rate.Limiter) {
     defer func() {
         if r := recover(); r != nil {
             fmt.Fprintf(os.Stderr, "Worker panic: %v\n", r)
@@ -581,7 +792,7 @@ func processURLs(ctx context.Context, urls <-chan string, results chan<- *Result
         select {
         case <-ctx.Done():
             return
-        case url, ok := <-urls:
+        case target, ok := <-urls:
             if !ok {
                 return
             }
@@ -593,21 +804,49 @@ func processURLs(ctx context.Context, urls <-chan string, results chan<- *Result
                 }
             }
             
-            result := client.CheckURL(ctx, url, config)
+            // One span per target, probes and requests are children
+            ctx, span := tracer.Start(ctx, "target", trace.WithAttributes(attribute.String("alivehunter.target", target.URL)))
             
-            // Update stats atomically
-            atomic.AddUint64(&stats.checked, 1)
-            if result.Alive {
-                atomic.AddUint64(&stats.alive, 1)
+            var checked []*Result
+            if config.ProbeStrategy == ProbeDual {
+                checked = client.CheckURLDual(ctx, target.URL, config)
+            } else {
+                checked = []*Result{client.CheckURL(ctx, target.URL, config)}
             }
-            if result.Verified {
-                atomic.AddUint64(&stats.verified, 1)
+            
+            // Compare both schemes once per target
+            var schemes *SchemeReport
+            if config.SchemeAudit {
+                schemes = client.AuditSchemes(ctx, target.URL)
             }
-            if result.Error != "" {
-                atomic.AddUint64(&stats.errors, 1)
+            
+            for _, result := range checked {
+                result.Meta = target.Meta
+                result.Schemes = schemes
+                if config.H2C {
+                    client.applyH2C(ctx, result, config)
+                }
+                if config.BannerGrab {
+                    client.applyBanner(ctx, result, config)
+                }
+                
+                // Update stats atomically
+                atomic.AddUint64(&stats.checked, 1)
+                if result.Alive {
+                    atomic.AddUint64(&stats.alive, 1)
+                }
+                if result.Verified {
+                    atomic.AddUint64(&stats.verified, 1)
+                }
+                if result.Error != "" {
+                    atomic.AddUint64(&stats.errors, 1)
+                }
             }
+            span.End()
             
-            results <- result
+            for _, result := range checked {
+                results <- result
+            }
         }
     }
 }
@@ -632,19 +871,17 @@ func displayProgress(ctx context.Context, stats *Stats, config *Config) {
 }
 
 // readInput reads URLs from stdin or file for pipeline compatibility and direct file usage
-func readInput(filename string) ([]string, error) {
-    var scanner *bufio.Scanner
-    var file *os.File
-    var err error
+func readInput(filename string, opts InputOptions) ([]Target, error) {
+    var reader io.Reader
 
     if filename != "" {
         // Read from file specified with -l flag
-        file, err = os.Open(filename)
+        file, err := os.Open(filename)
         if err != nil {
             return nil, fmt.Errorf("error opening file %s: %v", filename, err)
         }
         defer file.Close()
-        scanner = bufio.NewScanner(file)
+        reader = file
         
         // Print file info for user feedback
         if stat, err := file.Stat(); err == nil {
@@ -661,8 +898,34 @@ func readInput(filename string) ([]string, error) {
             return nil, errors.New("no input provided via pipe or file (-l)")
         }
         
-        scanner = bufio.NewScanner(os.Stdin)
+        reader = os.Stdin
     }
+
+    // JSON lines from discovery tools, with field selection and metadata
+    if opts.Format == InputFormatJSONL {
+        targets, err := parseJSONLines(reader, opts)
+        if err != nil {
+            return nil, fmt.Errorf("error reading jsonl input: %v", err)
+        }
+        if len(targets) == 0 {
+            return nil, errors.New("no valid URLs found in jsonl input")
+        }
+        return targets, nil
+    }
+
+    // Port scanner output carries host:port pairs with scheme hints
+    if opts.Format != "" && opts.Format != InputFormatLines {
+        urls, err := parsePortScanInput(reader, opts.Format)
+        if err != nil {
+            return nil, fmt.Errorf("error reading %s input: %v", opts.Format, err)
+        }
+        if len(urls) == 0 {
+            return nil, fmt.Errorf("no open ports found in %s input", opts.Format)
+        }
+        return newTargets(urls), nil
+    }
+
+    scanner := bufio.NewScanner(reader)
     
     // Optimize scanner for large files (bug bounty scope files can be huge)
     scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024) // 2MB max line for safety
@@ -679,11 +942,8 @@ func readInput(filename string) ([]string, error) {
             continue
         }
         
-        // Clean up the URL (remove protocol if present for consistent processing)
-        cleanURL := strings.TrimPrefix(strings.TrimPrefix(line, "https://"), "http://")
-        if cleanURL != "" {
-            urls = append(urls, cleanURL)
-        }
+        // Keep any explicit scheme, the probe strategy decides how to use it
+        urls = append(urls, line)
         
         // Progress feedback for large files
         if filename != "" && lineCount%5000 == 0 {
@@ -703,56 +963,102 @@ func readInput(filename string) ([]string, error) {
         }
     }
 
-    return urls, nil
+    return newTargets(urls), nil
 }
 
-// outputResult formats and outputs a single result with different output modes
-func outputResult(result *Result, config *Config, outputWriter io.Writer) {
-    // Only show alive URLs unless explicitly requested to show failed
-    if !result.Alive && !config.ShowFailed {
+// shouldOutput reports whether a result is shown for the given config
+func shouldOutput(result *Result, config *Config) bool {
+    // Diff and watch modes only show what changed since the baseline
+    if (config.DiffBaseline != "" || config.Watch) && result.Diff == "" {
+        return false
+    }
+    
+    // Only show alive URLs (and detected services) unless explicitly requested to show failed
+    return result.Alive || config.ShowFailed || result.Service != "" || result.Diff != ""
+}
+
+// outputResult writes a single result through the selected formatter
+func outputResult(result *Result, config *Config, formatter ResultFormatter) {
+    if !shouldOutput(result, config) {
         return
     }
     
-    if config.JSONOutput {
-        // JSON output for programmatic processing
-        data, _ := json.Marshal(result)
-        fmt.Fprintln(outputWriter, string(data))
-    } else if config.Silent || config.CleanOutput {
-        // Clean output for pipelines (perfect for nuclei, httpx, etc.)
-        if result.Alive {
-            fmt.Fprintln(outputWriter, result.URL)
-        } else if config.ShowFailed {
-            fmt.Fprintln(outputWriter, result.URL+" [FAILED]")
-        }
-    } else {
-        // Detailed output for human consumption
-        if result.Alive {
-            output := result.URL
-            
-            // Add title if available
-            if config.ExtractTitle && result.Title != "" {
-                output += " [" + result.Title + "]"
-            }
-            
-            // Add status code if not 200
-            if result.Status != 200 {
-                output += fmt.Sprintf(" [%d]", result.Status)
-            }
-            
-            // Add verification status
-            if result.Verified {
-                output += " [VERIFIED]"
-            }
-            
-            // Add redirect info if present
-            if result.Redirect != "" {
-                output += fmt.Sprintf(" -> %s", result.Redirect)
-            }
-            
-            fmt.Fprintln(outputWriter, output)
-        } else if config.ShowFailed {
-            fmt.Fprintf(outputWriter, "%s [FAILED: %s]\n", result.URL, result.Error)
-        }
+    if err := formatter.Format(result); err != nil {
+        fmt.Fprintf(os.Stderr, "Error writing result: %v\n", err)
+    }
+}
+
+// defaultConfig returns the default configuration optimized for bug bounty
+func defaultConfig() *Config {
+    return &Config{
+        Workers:       DEFAULT_WORKERS,
+        Rate:          DEFAULT_RATE,
+        Timeout:       DEFAULT_TIMEOUT,
+        MaxBodySize:   MAX_BODY_SIZE,
+        OnlyStatus:    []int{},
+        TLSMinVersion: tls.VersionTLS12,
+        ProbeStrategy: ProbeRespect,
+    }
+}
+
+// scanFlags registers the flags bound directly to scan settings in config;
+// profiles are applied through the same flags
+func scanFlags(fs *flag.FlagSet, config *Config) {
+    fs.IntVar(&config.Workers, "t", config.Workers, "Number of threads")
+    fs.IntVar(&config.Workers, "threads", config.Workers, "Number of threads (alias)")
+    fs.Float64Var(&config.Rate, "rate", config.Rate, "Requests per second")
+    fs.DurationVar(&config.Timeout, "timeout", config.Timeout, "Request timeout")
+    fs.BoolVar(&config.ExtractTitle, "title", config.ExtractTitle, "Extract page titles")
+    fs.BoolVar(&config.RobustTitle, "robust-title", config.RobustTitle, "Use robust HTML parser for titles (slower)")
+    fs.BoolVar(&config.FastMode, "fast", config.FastMode, "Fast mode for large scope files (built-in profile)")
+    fs.BoolVar(&config.VerifyMode, "verify", config.VerifyMode, "Verify mode, zero false positives (built-in profile)")
+    fs.BoolVar(&config.FollowRedirect, "follow-redirects", config.FollowRedirect, "Follow HTTP redirects")
+    fs.BoolVar(&config.ShowFailed, "show-failed", config.ShowFailed, "Show failed requests")
+    fs.StringVar(&config.ProbeStrategy, "probe", config.ProbeStrategy, "Scheme probing: respect, both, https, http, dual")
+    fs.BoolVar(&config.SchemeAudit, "scheme-audit", config.SchemeAudit, "Report HTTP and HTTPS outcomes, HTTPS upgrade and HSTS")
+    fs.BoolVar(&config.HTTP2, "http2", config.HTTP2, "Negotiate HTTP/2 via ALPN and report the protocol")
+    fs.BoolVar(&config.HTTP3, "http3", config.HTTP3, "Probe HTTP/3 (QUIC) via Alt-Svc or direct attempt")
+    fs.BoolVar(&config.H2C, "h2c", config.H2C, "Detect cleartext HTTP/2 (prior knowledge and Upgrade: h2c)")
+    fs.BoolVar(&config.BannerGrab, "banner", config.BannerGrab, "Grab banners and detect non-HTTP services on failed targets")
+}
+
+// parseTLSVersion maps "1.0" to "1.3" to a TLS version, defaulting to 1.2
+func parseTLSVersion(version string) uint16 {
+    switch version {
+    case "1.0":
+        return tls.VersionTLS10
+    case "1.1":
+        return tls.VersionTLS11
+    case "1.3":
+        return tls.VersionTLS13
+    default:
+        return tls.VersionTLS12
+    }
+}
+
+// validateProbeStrategy checks a -probe value
+func validateProbeStrategy(strategy string) error {
+    switch strategy {
+    case ProbeRespect, ProbeBoth, ProbeHTTPS, ProbeHTTP, ProbeDual:
+        return nil
+    }
+    return fmt.Errorf("%q (use respect, both, https, http or dual)", strategy)
+}
+
+// applyOperationMode reports -fast and -verify, whose tuning comes from the
+// built-in profiles, and caps workers for the machine
+func applyOperationMode(config *Config) {
+    if config.FastMode && !config.Silent {
+        fmt.Fprintf(os.Stderr, "Fast mode enabled: %d workers, %.0f req/s\n", config.Workers, config.Rate)
+    }
+    if config.VerifyMode && !config.Silent {
+        fmt.Fprintf(os.Stderr, "Verify mode enabled: %d workers, comprehensive validation\n", config.Workers)
+    }
+
+    // System optimization
+    maxWorkers := runtime.NumCPU() * 50
+    if config.Workers > maxWorkers {
+        config.Workers = maxWorkers
     }
 }
 
@@ -766,6 +1072,9 @@ func main() {
         color.New(color.FgHiGreen).Println("🎯 USAGE")
         fmt.Println("  alivehunter -l domains.txt [options]        # From file")
         fmt.Println("  cat domains.txt | alivehunter [options]     # From pipe")
+        fmt.Println("  alivehunter -scope h1_scope.csv [options]   # From platform scope export")
+        fmt.Println("  alivehunter watch -l domains.txt -interval 6h  # Re-scan and report changes")
+        fmt.Println("  alivehunter serve -listen 127.0.0.1:8080 -token X  # HTTP API (see README)")
         
         fmt.Println("\n" + strings.Repeat("=", 70))
         color.New(color.FgHiGreen).Println("🚀 OPERATION MODES")
@@ -825,20 +1134,77 @@ func main() {
         
         color.New(color.FgYellow).Println("\n  Core Performance:")
         fmt.Println("    -l string          Input file with domains/URLs")
+        fmt.Println("    -scope string      HackerOne/Bugcrowd/Intigriti scope export (CSV or JSON)")
+        fmt.Println("    -bounty-only       Only scan scope assets eligible for bounty")
+        fmt.Println("    -input-format      Input format: lines, jsonl, nmap, masscan, naabu (default: lines)")
+        fmt.Println("    -input-field       Dot-path of the host field for jsonl input (default: host)")
+        fmt.Println("    -input-meta        jsonl fields to carry into JSON results (e.g. source,a)")
+        fmt.Println("    -no-normalize      Keep input as-is (no lowercasing, punycode or dedup)")
         fmt.Println("    -o string          Output file (default: stdout)")
         fmt.Println("    -t int             Number of threads (default: 100)")
         fmt.Println("    -rate float        Requests per second (default: 100)")
         fmt.Println("    -timeout duration  Request timeout (default: 3s)")
+        fmt.Println("    -config file       YAML/TOML config file (default: ~/.config/alivehunter/config.yaml)")
+        fmt.Println("    -profile name      Config file or built-in profile: fast, verify, stealth")
         
         color.New(color.FgYellow).Println("\n  Output Control:")
         fmt.Println("    -silent            Clean output for pipelines")
         fmt.Println("    -json              JSON output format")
+        fmt.Println("    -csv / -tsv        CSV or TSV output with header row")
+        fmt.Println("    -markdown          Markdown table output")
+        fmt.Println("    -columns string    Table columns (default: url,status_code,content_length,title,server,redirect,verified)")
+        fmt.Println("    -format string     Output template, e.g. '{{.URL}} {{.Status}} {{.Title}}'")
+        fmt.Println("    -format-file file  Read the output template from a file")
+        fmt.Println("    -html-report file  Also write a self-contained HTML report")
+        fmt.Println("    -db file           Store results and scan history in a SQLite database")
+        fmt.Println("    -store-response dir Save raw requests/responses per host with an index.jsonl")
+        fmt.Println("    -store-response-size Body bytes kept per stored response (default: 524288)")
+        fmt.Println("    -metrics-addr addr Expose Prometheus metrics, e.g. :9090")
+        fmt.Println("    -trace string      OpenTelemetry tracing: otlp (collector) or stdout (spans on stderr)")
+        fmt.Println("    -trace-endpoint    OTLP/HTTP collector address (default: $OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318)")
+        fmt.Println("    -trace-sample      Fraction of targets to trace (default: 1)")
+        fmt.Println("    -diff source       Only output new, gone and changed hosts vs a -json file or -db scan ID")
         fmt.Println("    -title             Extract page titles")
         fmt.Println("    -show-failed       Show failed requests")
         
+        color.New(color.FgYellow).Println("\n  Notifications:")
+        fmt.Println("    -notify-webhook    POST results and scan completion as JSON")
+        fmt.Println("    -notify-template   text/template for the webhook body")
+        fmt.Println("    -notify-slack      Slack incoming webhook URL")
+        fmt.Println("    -notify-discord    Discord webhook URL")
+        fmt.Println("    -notify-filter     Results to notify about (default: .Alive)")
+        fmt.Println("    -notify-batch int  Results per notification (default: 20)")
+        
+        color.New(color.FgYellow).Println("\n  Watch Mode (alivehunter watch ...):")
+        fmt.Println("    -interval duration Time between scans (default: 1h)")
+        fmt.Println("    -cron string       Cron schedule instead of -interval, e.g. '0 */6 * * *'")
+        fmt.Println("    SIGHUP             Reload the -l/-scope input without restarting")
+        
+        color.New(color.FgYellow).Println("\n  Distributed Scanning:")
+        fmt.Println("    -coordinator addr  Serve batches to workers on this address instead of scanning locally")
+        fmt.Println("    -token string      Token workers must send (default: $ALIVEHUNTER_API_TOKEN)")
+        fmt.Println("    -batch-size int    Targets per worker batch (default: 100)")
+        fmt.Println("    -lease duration    Re-assign a batch when its worker is silent this long (default: 2m)")
+        fmt.Println("    alivehunter worker -coordinator http://host:port [-token X] [-t N] [-rate R]")
+        
         color.New(color.FgYellow).Println("\n  Filtering & Matching:")
         fmt.Println("    -mc string         Match specific status codes (comma separated)")
+        fmt.Println("    -headers string    Include response headers in results: all, or names (comma separated)")
+        fmt.Println("    -match-header expr Only keep responses with a header: 'Name' or 'Name: regex' (repeatable)")
+        fmt.Println("    -filter-header expr Drop responses with a header: 'Name' or 'Name: regex' (repeatable)")
+        fmt.Println("    -mr / -fr regex    Match / filter body regex (repeatable)")
+        fmt.Println("    -ms / -fs string   Match / filter body substring (repeatable)")
+        fmt.Println("    -ml / -fl ranges   Match / filter content length, e.g. 0,100-200")
+        fmt.Println("    -mwc / -fwc ranges Match / filter body word count")
+        fmt.Println("    -mlc / -flc ranges Match / filter body line count")
+        fmt.Println("    -mrt / -frt cond   Match / filter response time, e.g. '<500ms' or '>2s'")
         fmt.Println("    -follow-redirects  Follow HTTP redirects")
+        fmt.Println("    -probe string      Scheme probing: respect, both, https, http, dual (default: respect)")
+        fmt.Println("    -scheme-audit      Report HTTP/HTTPS outcomes, HTTP→HTTPS upgrade and HSTS")
+        fmt.Println("    -http2             Negotiate HTTP/2 via ALPN and report the protocol")
+        fmt.Println("    -http3             Probe HTTP/3 (QUIC) via Alt-Svc or direct attempt")
+        fmt.Println("    -h2c               Detect cleartext HTTP/2 (prior knowledge and Upgrade: h2c)")
+        fmt.Println("    -banner            Grab banners and detect non-HTTP services (SSH, SMTP, Redis...)")
         fmt.Println("    -tls-min string    Minimum TLS version (1.0, 1.1, 1.2, 1.3)")
         
         fmt.Println("\n" + strings.Repeat("=", 70))
@@ -866,6 +1232,9 @@ func main() {
         fmt.Println("  amass enum -d target.com | alivehunter -fast -silent > live.txt")
         fmt.Println("  cat scope.txt | alivehunter -silent | httpx -title -tech")
         fmt.Println("  alivehunter -l scope.txt -json | jq -r '.url' | custom_tool")
+        fmt.Println("  nmap -p- -oX - target.com | alivehunter -input-format nmap -silent")
+        fmt.Println("  naabu -host target.com -json | alivehunter -input-format naabu -silent")
+        fmt.Println("  subfinder -d target.com -oJ | alivehunter -input-format jsonl -input-meta source -json")
         
         fmt.Println()
         color.New(color.FgHiGreen).Println("Made with ❤️ by Albert.C")
@@ -874,54 +1243,144 @@ func main() {
         return
     }
 
-    // Default configuration optimized for bug bounty
-    config := &Config{
-        Workers:       DEFAULT_WORKERS,
-        Rate:         DEFAULT_RATE,
-        Timeout:      DEFAULT_TIMEOUT,
-        MaxBodySize:   MAX_BODY_SIZE,
-        OnlyStatus:    []int{},
-        TLSMinVersion: tls.VersionTLS12,
+    // "serve" subcommand: HTTP API for submitting and streaming scans
+    if len(os.Args) > 1 && os.Args[1] == "serve" {
+        runServe(os.Args[2:])
+        return
+    }
+
+    // "worker" subcommand: scan batches leased from a -coordinator
+    if len(os.Args) > 1 && os.Args[1] == "worker" {
+        runWorker(os.Args[2:])
+        return
+    }
+
+    // "watch" subcommand: re-scan on a schedule, same flags otherwise
+    watchMode := len(os.Args) > 1 && os.Args[1] == "watch"
+    if watchMode {
+        os.Args = append(os.Args[:1], os.Args[2:]...)
     }
 
+    // Default configuration optimized for bug bounty
+    config := defaultConfig()
+    config.Watch = watchMode
+
     // Command line flags
+    configFile := flag.String("config", "", "YAML or TOML config file (default: ~/.config/alivehunter/config.yaml if present)")
+    profileName := flag.String("profile", "", "Named profile from the config file or built-in: fast, verify, stealth")
     inputFile := flag.String("l", "", "Input file containing URLs/domains to check")
+    scopeFile := flag.String("scope", "", "Bug bounty scope export (HackerOne/Bugcrowd/Intigriti CSV or JSON)")
+    bountyOnly := flag.Bool("bounty-only", false, "Only scan scope assets eligible for bounty")
+    inputFormat := flag.String("input-format", InputFormatLines, "Input format: lines, jsonl, nmap, masscan, naabu")
+    inputField := flag.String("input-field", "host", "Dot-path of the host/URL field for jsonl input")
+    inputMeta := flag.String("input-meta", "", "Dot-paths of jsonl fields to carry into results (comma separated)")
+    noNormalize := flag.Bool("no-normalize", false, "Disable input normalisation and deduplication")
     outputFile := flag.String("o", "", "Output file to save results (default: stdout)")
     flag.BoolVar(&config.CleanOutput, "clean", false, "Clean output (URLs only, perfect for pipelines)")
-    flag.IntVar(&config.Workers, "t", config.Workers, "Number of threads")
-    flag.IntVar(&config.Workers, "threads", config.Workers, "Number of threads (alias)")
-    flag.Float64Var(&config.Rate, "rate", config.Rate, "Requests per second")
-    flag.DurationVar(&config.Timeout, "timeout", config.Timeout, "Request timeout")
+    scanFlags(flag.CommandLine, config)
     flag.BoolVar(&config.Silent, "silent", false, "Silent mode (clean output, pipeline friendly)")
     flag.BoolVar(&config.JSONOutput, "json", false, "JSON output")
-    flag.BoolVar(&config.ExtractTitle, "title", false, "Extract page titles")
-    flag.BoolVar(&config.RobustTitle, "robust-title", false, "Use robust HTML parser for titles (slower)")
-    flag.BoolVar(&config.FastMode, "fast", false, "Fast mode for large scope files")
-    flag.BoolVar(&config.VerifyMode, "verify", false, "Verify mode (zero false positives)")
-    flag.BoolVar(&config.FollowRedirect, "follow-redirects", false, "Follow HTTP redirects")
-    flag.BoolVar(&config.ShowFailed, "show-failed", false, "Show failed requests")
+    csvOutput := flag.Bool("csv", false, "CSV output with header row")
+    tsvOutput := flag.Bool("tsv", false, "TSV output with header row")
+    markdownOutput := flag.Bool("markdown", false, "Markdown table output")
+    columns := flag.String("columns", "", "Table columns as JSON field names (comma separated)")
+    format := flag.String("format", "", "Output template, e.g. '{{.URL}} {{.Status}} {{.Title}}'")
+    formatFile := flag.String("format-file", "", "File containing the output template")
+    htmlReport := flag.String("html-report", "", "Write a self-contained HTML report to this file")
+    flag.StringVar(&config.StoreResponse, "store-response", "", "Store raw responses in this directory with an index.jsonl")
+    storeResponseSize := flag.Int64("store-response-size", DEFAULT_STORE_RESPONSE_SIZE, "Body bytes kept per stored response")
+    dbFile := flag.String("db", "", "SQLite database to store results and scan history")
+    var notifyOpts NotifyOptions
+    flag.StringVar(&notifyOpts.Webhook, "notify-webhook", "", "POST results and scan completion as JSON to this URL")
+    flag.StringVar(&notifyOpts.Template, "notify-template", "", "text/template for the -notify-webhook body")
+    flag.StringVar(&notifyOpts.Slack, "notify-slack", "", "Slack incoming webhook URL")
+    flag.StringVar(&notifyOpts.Discord, "notify-discord", "", "Discord webhook URL")
+    flag.StringVar(&notifyOpts.Filter, "notify-filter", DEFAULT_NOTIFY_FILTER, "Template expression selecting results to notify about")
+    flag.IntVar(&notifyOpts.Batch, "notify-batch", NOTIFY_BATCH_SIZE, "Results per notification")
+    watchInterval := flag.Duration("interval", DEFAULT_WATCH_INTERVAL, "Watch mode: time between scans")
+    watchCron := flag.String("cron", "", "Watch mode: cron schedule for scans, e.g. '0 */6 * * *' (overrides -interval)")
+    traceExporter := flag.String("trace", "", "OpenTelemetry tracing exporter: otlp or stdout")
+    traceEndpoint := flag.String("trace-endpoint", "", "OTLP/HTTP collector, e.g. localhost:4318 (default: $OTEL_EXPORTER_OTLP_ENDPOINT)")
+    traceSample := flag.Float64("trace-sample", 1.0, "Fraction of targets to trace (0-1)")
+    metricsAddr := flag.String("metrics-addr", "", "Expose Prometheus metrics on this address, e.g. :9090")
+    coordinatorAddr := flag.String("coordinator", "", "Serve batches to workers on this address instead of scanning locally")
+    coordinatorToken := flag.String("token", os.Getenv("ALIVEHUNTER_API_TOKEN"), "Token workers must send to the coordinator")
+    batchSize := flag.Int("batch-size", DEFAULT_BATCH_SIZE, "Coordinator: targets per worker batch")
+    leaseTimeout := flag.Duration("lease", DEFAULT_LEASE_TIMEOUT, "Coordinator: re-assign a batch when its worker is silent this long")
+    flag.StringVar(&config.DiffBaseline, "diff", "", "Only output changes against a previous -json results file or -db scan ID")
     
     statusCodes := flag.String("mc", "", "Match status codes (comma separated)")
+    headerNames := flag.String("headers", "", "Include response headers in results: all, or names (comma separated)")
+    flag.Var(headerMatcherFlag{&config.Match.Headers}, "match-header", "Only keep responses with this header: 'Name' or 'Name: regex' (repeatable)")
+    flag.Var(headerMatcherFlag{&config.Filter.Headers}, "filter-header", "Drop responses with this header: 'Name' or 'Name: regex' (repeatable)")
+    matcherFlags(flag.CommandLine, &config.Match, "m", "Match")
+    matcherFlags(flag.CommandLine, &config.Filter, "f", "Filter")
     tlsVersion := flag.String("tls-min", "1.2", "Minimum TLS version (1.0, 1.1, 1.2, 1.3)")
     flag.Parse()
 
+    // Config file and profile settings; command line flags take precedence
+    configPath := *configFile
+    if configPath == "" {
+        configPath = defaultConfigPath()
+    }
+    var fileConfig *ConfigFile
+    if configPath != "" {
+        var err error
+        if fileConfig, err = LoadConfigFile(configPath); err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+    }
+    if err := applyConfig(flag.CommandLine, config, fileConfig, *profileName); err != nil {
+        fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+        os.Exit(1)
+    }
+    if configPath != "" && !config.Silent {
+        fmt.Fprintf(os.Stderr, "Using config file %s\n", configPath)
+    }
+
     // Auto-enable clean mode if silent is used
     if config.Silent {
         config.CleanOutput = true
     }
 
     // Parse TLS version
-    switch *tlsVersion {
-    case "1.0":
-        config.TLSMinVersion = tls.VersionTLS10
-    case "1.1":
-        config.TLSMinVersion = tls.VersionTLS11
-    case "1.2":
-        config.TLSMinVersion = tls.VersionTLS12
-    case "1.3":
-        config.TLSMinVersion = tls.VersionTLS13
-    default:
-        config.TLSMinVersion = tls.VersionTLS12
+    config.TLSMinVersion = parseTLSVersion(*tlsVersion)
+
+    // Select table output format and columns
+    switch {
+    case *csvOutput:
+        config.TableFormat = TableCSV
+    case *tsvOutput:
+        config.TableFormat = TableTSV
+    case *markdownOutput:
+        config.TableFormat = TableMarkdown
+    }
+    for _, column := range strings.Split(*columns, ",") {
+        if column = strings.TrimSpace(column); column != "" {
+            config.Columns = append(config.Columns, column)
+        }
+    }
+
+    // Select the output template
+    outputTemplate, err := loadTemplate(*format, *formatFile, config)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "%v\n", err)
+        os.Exit(1)
+    }
+    config.OutputTemplate = outputTemplate
+
+    // Validate probe strategy
+    if err := validateProbeStrategy(config.ProbeStrategy); err != nil {
+        fmt.Fprintf(os.Stderr, "Invalid -probe value: %v\n", err)
+        os.Exit(1)
+    }
+
+    // Parse header names to capture
+    for _, name := range strings.Split(*headerNames, ",") {
+        if name = strings.TrimSpace(name); name != "" {
+            config.Headers = append(config.Headers, name)
+        }
     }
 
     // Parse status codes
@@ -936,29 +1395,7 @@ func main() {
         sort.Ints(config.OnlyStatus)
     }
 
-    // Auto-optimize for bug bounty workloads
-    if config.FastMode {
-        config.Workers *= 2
-        config.Rate *= 2
-        config.Timeout = 1 * time.Second
-        if !config.Silent {
-            fmt.Fprintf(os.Stderr, "Fast mode enabled: %d workers, %.0f req/s\n", config.Workers, config.Rate)
-        }
-    }
-    
-    if config.VerifyMode {
-        config.Workers = max(config.Workers/2, 10)
-        config.Timeout = 10 * time.Second
-        if !config.Silent {
-            fmt.Fprintf(os.Stderr, "Verify mode enabled: %d workers, comprehensive validation\n", config.Workers)
-        }
-    }
-
-    // System optimization
-    maxWorkers := runtime.NumCPU() * 50
-    if config.Workers > maxWorkers {
-        config.Workers = maxWorkers
-    }
+    applyOperationMode(config)
 
     // Setup graceful shutdown
     ctx, cancel := context.WithCancel(context.Background())
@@ -974,21 +1411,78 @@ func main() {
         cancel()
     }()
 
-    // Read input (from file or stdin)
-    urls, err := readInput(*inputFile)
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-        if *inputFile == "" {
-            fmt.Fprintf(os.Stderr, "Usage: %s -l domains.txt [options] OR cat domains.txt | %s [options]\n", os.Args[0], os.Args[0])
+    // Read input (from scope export, file or stdin)
+    inputOpts := InputOptions{Format: *inputFormat, Field: *inputField}
+    for _, path := range strings.Split(*inputMeta, ",") {
+        if path = strings.TrimSpace(path); path != "" {
+            inputOpts.Meta = append(inputOpts.Meta, path)
+        }
+    }
+    loadTargets := func() ([]Target, error) {
+        var targets []Target
+        var err error
+        if *scopeFile != "" {
+            targets, err = readScopeInput(*scopeFile, *bountyOnly)
+        } else {
+            targets, err = readInput(*inputFile, inputOpts)
         }
+        if err != nil {
+            return nil, err
+        }
+
+        // Canonicalise and deduplicate so no rate budget is spent on repeats
+        if !*noNormalize {
+            var normStats NormalizeStats
+            targets, normStats = normalizeTargets(targets)
+            if len(targets) == 0 {
+                return nil, fmt.Errorf("no valid URLs left after normalisation")
+            }
+            if !config.Silent && (normStats.Duplicates > 0 || normStats.Invalid > 0) {
+                fmt.Fprintf(os.Stderr, "Normalised input: %d duplicates collapsed, %d invalid dropped\n", normStats.Duplicates, normStats.Invalid)
+                if normStats.Bloom {
+                    fmt.Fprintf(os.Stderr, "Large input: deduplicated with a Bloom filter (rare false positives possible)\n")
+                }
+            }
+        }
+
+        if !config.Silent {
+            fmt.Fprintf(os.Stderr, "Loaded %d URLs for validation\n", len(targets))
+            if len(targets) > 5000 {
+                fmt.Fprintf(os.Stderr, "Large scope detected. Consider using -fast for initial filtering.\n")
+            }
+        }
+        return targets, nil
+    }
+
+    // Watch mode re-reads the input on SIGHUP, stdin can only be read once
+    if config.Watch {
+        if *inputFile == "" && *scopeFile == "" {
+            fmt.Fprintf(os.Stderr, "Watch mode requires -l or -scope\n")
+            os.Exit(1)
+        }
+        if *htmlReport != "" {
+            fmt.Fprintf(os.Stderr, "-html-report is not supported in watch mode\n")
+            os.Exit(1)
+        }
+        if *coordinatorAddr != "" {
+            fmt.Fprintf(os.Stderr, "-coordinator is not supported in watch mode\n")
+            os.Exit(1)
+        }
+    }
+
+    // The coordinator never scans, so options acting on local requests would do nothing
+    if *coordinatorAddr != "" && (config.StoreResponse != "" || *traceExporter != "") {
+        fmt.Fprintf(os.Stderr, "-store-response and -trace are not supported with -coordinator\n")
         os.Exit(1)
     }
 
-    if !config.Silent {
-        fmt.Fprintf(os.Stderr, "Loaded %d URLs for validation\n", len(urls))
-        if len(urls) > 5000 {
-            fmt.Fprintf(os.Stderr, "Large scope detected. Consider using -fast for initial filtering.\n")
+    targets, err := loadTargets()
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+        if *inputFile == "" && *scopeFile == "" {
+            fmt.Fprintf(os.Stderr, "Usage: %s -l domains.txt [options] OR cat domains.txt | %s [options]\n", os.Args[0], os.Args[0])
         }
+        os.Exit(1)
     }
 
     // Setup output (to file or stdout)
@@ -1007,20 +1501,236 @@ func main() {
         outputWriter = os.Stdout
     }
 
+    // Setup result output: tables write their header immediately, everything
+    // else is rendered through a template
+    var formatter ResultFormatter
+    if config.TableFormat != "" {
+        formatter, err = NewTableFormatter(outputWriter, config.TableFormat, config.Columns)
+    } else {
+        formatter, err = NewTemplateFormatter(outputWriter, config.OutputTemplate, config)
+    }
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Error setting up output: %v\n", err)
+        os.Exit(1)
+    }
+
     // Initialize performance tracking
     stats := &Stats{
         started:   time.Now(),
-        totalUrls: int64(len(urls)),
+        totalUrls: int64(len(targets)),
+    }
+
+    // The HTML report receives the same results alongside the main output
+    if *htmlReport != "" {
+        report, err := NewHTMLReport(*htmlReport, stats)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+        formatter = multiFormatter{formatter, report}
+    }
+
+    // Notifications see the same results as the output
+    var notifier *Notifier
+    if notifyOpts.Enabled() {
+        notifier, err = NewNotifier(notifyOpts, config)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+        formatter = multiFormatter{formatter, notifier}
+    }
+
+    // Open the result store and load the diff baseline (a scan ID baseline
+    // must be read before this scan is recorded)
+    var store *Store
+    var differ *Differ
+    if *dbFile != "" {
+        store, err = OpenStore(*dbFile)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+        defer store.Close()
+    }
+    if config.DiffBaseline != "" {
+        differ, err = LoadBaseline(config.DiffBaseline, store)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+    }
+
+    if *traceExporter != "" {
+        shutdownTracing, err := setupTracing(ctx, *traceExporter, *traceEndpoint, *traceSample)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+        defer shutdownTracing(context.Background())
+        config.Trace = true
+    }
+
+    client := NewAliveHTTPClient(config)
+    defer client.Close()
+    if config.StoreResponse != "" {
+        responses, err := NewResponseStore(config.StoreResponse, *storeResponseSize)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+        defer responses.Close()
+        client.responses = responses
+    }
+
+    var metrics *Metrics
+    if *metricsAddr != "" {
+        metrics = NewMetrics(config)
+        if err := ServeMetrics(*metricsAddr, metrics); err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+        if !config.Silent {
+            fmt.Fprintf(os.Stderr, "Metrics available at http://%s/metrics\n", *metricsAddr)
+        }
     }
 
+    if config.Watch {
+        watcher := &Watcher{
+            config:      config,
+            client:      client,
+            formatter:   formatter,
+            notifier:    notifier,
+            metrics:     metrics,
+            store:       store,
+            differ:      differ,
+            loadTargets: loadTargets,
+            targets:     targets,
+        }
+        if err := watcher.Run(ctx, *watchInterval, *watchCron); err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+        formatter.Close()
+        return
+    }
+
+    var scanID int64
+    if store != nil {
+        scanID, err = store.StartScan(config, stats.totalUrls)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+    }
+
+    // Process and output results
+    aliveCount := int64(0)
+    if metrics != nil {
+        metrics.ScanStarted(stats.totalUrls)
+    }
+    handle := func(result *Result) {
+        if result.Alive {
+            aliveCount++
+        }
+        if metrics != nil {
+            metrics.Observe(result)
+        }
+        
+        if differ != nil {
+            differ.Apply(result)
+        }
+        
+        // Every result is stored, including those not shown
+        if store != nil {
+            if err := store.Save(result); err != nil {
+                fmt.Fprintf(os.Stderr, "Error saving result: %v\n", err)
+            }
+        }
+        
+        outputResult(result, config, formatter)
+    }
+    if *coordinatorAddr != "" {
+        // Workers do the scanning; their results go through the same handler
+        if err := runCoordinator(ctx, *coordinatorAddr, *coordinatorToken, targets, config, stats, *batchSize, *leaseTimeout, handle); err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+            os.Exit(1)
+        }
+    } else {
+        runScan(ctx, targets, client, config, stats, handle)
+    }
+    if metrics != nil {
+        metrics.ScanFinished()
+    }
+
+    // Baseline hosts this run never looked at are gone too; an interrupted
+    // scan did not look at everything, so it reports none
+    if differ != nil && ctx.Err() == nil {
+        for _, result := range differ.Missing() {
+            outputResult(result, config, formatter)
+        }
+    }
+
+    if notifier != nil {
+        summary := stats.String()
+        if differ != nil {
+            summary += " | " + differ.String()
+        }
+        notifier.ScanComplete(summary)
+    }
+    if err := formatter.Close(); err != nil {
+        fmt.Fprintf(os.Stderr, "Error writing results: %v\n", err)
+    }
+    if store != nil {
+        if err := store.FinishScan(stats, ctx.Err() != nil); err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+        }
+    }
+
+    // Final statistics
+    if !config.Silent {
+        fmt.Fprintf(os.Stderr, "\n" + strings.Repeat("=", 60) + "\n")
+        fmt.Fprintf(os.Stderr, "Scan completed: %s\n", stats.String())
+        elapsed := time.Since(stats.started)
+        fmt.Fprintf(os.Stderr, "Total time: %v\n", elapsed.Round(time.Second))
+        
+        total := int64(len(targets))
+        if config.ProbeStrategy == ProbeDual {
+            total *= 2 // One result per scheme
+        }
+        successRate := float64(aliveCount) / float64(total) * 100
+        
+        fmt.Fprintf(os.Stderr, "Results: %d/%d alive (%.1f%%)\n", aliveCount, total, successRate)
+        if differ != nil {
+            fmt.Fprintf(os.Stderr, "%s\n", differ.String())
+        }
+        
+        if *outputFile != "" {
+            fmt.Fprintf(os.Stderr, "Results saved to: %s\n", *outputFile)
+        }
+        if *htmlReport != "" {
+            fmt.Fprintf(os.Stderr, "HTML report saved to: %s\n", *htmlReport)
+        }
+        if store != nil {
+            fmt.Fprintf(os.Stderr, "Scan %d stored in: %s\n", scanID, *dbFile)
+        }
+        
+        color.New(color.FgHiGreen).Fprintf(os.Stderr, "\nMade with ❤️ by Albert.C\n")
+    }
+}
+
+// runScan feeds targets to the worker pool and passes every result to handle
+// from a single goroutine; it returns once all results have been handled
+func runScan(ctx context.Context, targets []Target, client *AliveHTTPClient, config *Config, stats *Stats, handle func(*Result)) {
     // Setup worker coordination
-    urlChan := make(chan string, BATCH_SIZE)
+    urlChan := make(chan Target, BATCH_SIZE)
     resultsChan := make(chan *Result, BATCH_SIZE)
     limiter := rate.NewLimiter(rate.Limit(config.Rate), 1)
-    client := NewAliveHTTPClient(config)
 
-    // Start progress monitoring
-    go displayProgress(ctx, stats, config)
+    // Start progress monitoring for this scan only
+    progressCtx, stopProgress := context.WithCancel(ctx)
+    defer stopProgress()
+    go displayProgress(progressCtx, stats, config)
 
     // Launch worker pool
     var wg sync.WaitGroup
@@ -1032,32 +1742,14 @@ func main() {
         }()
     }
 
-    // Process and output results
-    outputMutex := &sync.Mutex{}
-    aliveCount := int64(0)
-    resultsDone := make(chan struct{})
-    
-    go func() {
-        defer close(resultsDone)
-        for result := range resultsChan {
-            if result.Alive {
-                atomic.AddInt64(&aliveCount, 1)
-            }
-            
-            outputMutex.Lock()
-            outputResult(result, config, outputWriter)
-            outputMutex.Unlock()
-        }
-    }()
-
     // Feed URLs to workers
     go func() {
         defer close(urlChan)
-        for _, url := range urls {
+        for _, target := range targets {
             select {
             case <-ctx.Done():
                 return
-            case urlChan <- url:
+            case urlChan <- target:
             }
         }
     }()
@@ -1068,34 +1760,7 @@ func main() {
         close(resultsChan)
     }()
 
-    // Wait for all results to be processed
-    <-resultsDone
-
-    // Final statistics
-    if !config.Silent {
-        fmt.Fprintf(os.Stderr, "\n" + strings.Repeat("=", 60) + "\n")
-        fmt.Fprintf(os.Stderr, "Scan completed: %s\n", stats.String())
-        elapsed := time.Since(stats.started)
-        fmt.Fprintf(os.Stderr, "Total time: %v\n", elapsed.Round(time.Second))
-        
-        alive := atomic.LoadInt64(&aliveCount)
-        total := int64(len(urls))
-        successRate := float64(alive) / float64(total) * 100
-        
-        fmt.Fprintf(os.Stderr, "Results: %d/%d alive (%.1f%%)\n", alive, total, successRate)
-        
-        if *outputFile != "" {
-            fmt.Fprintf(os.Stderr, "Results saved to: %s\n", *outputFile)
-        }
-        
-        color.New(color.FgHiGreen).Fprintf(os.Stderr, "\nMade with ❤️ by Albert.C\n")
-    }
-}
-
-// max returns the maximum of two integers
-func max(a, b int) int {
-    if a > b {
-        return a
+    for result := range resultsChan {
+        handle(result)
     }
-    return b
 }
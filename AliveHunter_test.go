@@ -0,0 +1,34 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestProbeURLs(t *testing.T) {
+    tests := []struct {
+        input    string
+        strategy string
+        want     []string
+    }{
+        {"example.com", ProbeRespect, []string{"https://example.com", "http://example.com"}},
+        {"https://example.com", ProbeRespect, []string{"https://example.com"}},
+        {"http://example.com/x", ProbeRespect, []string{"http://example.com/x"}},
+        {"HTTP://example.com", ProbeRespect, []string{"http://example.com"}},
+        {"example.com", ProbeBoth, []string{"https://example.com", "http://example.com"}},
+        {"https://example.com", ProbeBoth, []string{"https://example.com", "http://example.com"}},
+        {"http://example.com", ProbeBoth, []string{"http://example.com", "https://example.com"}},
+        {"example.com", ProbeHTTPS, []string{"https://example.com"}},
+        {"http://example.com:8080", ProbeHTTPS, []string{"https://example.com:8080"}},
+        {"example.com", ProbeHTTP, []string{"http://example.com"}},
+        {"https://example.com", ProbeHTTP, []string{"http://example.com"}},
+        {"example.com", ProbeDual, []string{"https://example.com", "http://example.com"}},
+        {"http://example.com", ProbeDual, []string{"http://example.com", "https://example.com"}},
+        {"https://example.com", ProbeDual, []string{"https://example.com", "http://example.com"}},
+    }
+    for _, tt := range tests {
+        if got := probeURLs(tt.input, tt.strategy); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("probeURLs(%q, %s) = %v, want %v", tt.input, tt.strategy, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+    "context"
+    "net"
+    "net/url"
+    "regexp"
+    "strings"
+    "time"
+)
+
+const (
+    BANNER_READ_SIZE = 512                    // Bytes read from a service banner
+    BANNER_MAX_LEN   = 128                    // Banner length kept in results
+    BANNER_WAIT      = 1500 * time.Millisecond // Time to wait for a spontaneous banner
+)
+
+// serviceSignature matches a banner to a service name
+type serviceSignature struct {
+    Service string
+    Pattern *regexp.Regexp
+}
+
+// Compile service signatures once; order matters, first match wins
+var serviceSignatures = []serviceSignature{
+    {"ssh", regexp.MustCompile(`^SSH-\d+\.\d+-`)},
+    {"ftp", regexp.MustCompile(`(?i)^220[ -].*ftp`)},
+    {"smtp", regexp.MustCompile(`(?i)^220[ -].*(smtp|mail|postfix|exim|sendmail)`)},
+    {"ftp", regexp.MustCompile(`^220[ -]`)},
+    {"pop3", regexp.MustCompile(`^\+OK`)},
+    {"imap", regexp.MustCompile(`^\* (OK|PREAUTH)`)},
+    {"redis", regexp.MustCompile(`^(\+PONG|-NOAUTH|-DENIED|-ERR (wrong number|unknown command|.*auth))`)},
+    {"memcached", regexp.MustCompile(`^(ERROR|CLIENT_ERROR)\r?\n`)},
+    {"mysql", regexp.MustCompile(`(?i)(mysql|mariadb)`)},
+    {"vnc", regexp.MustCompile(`^RFB \d{3}\.\d{3}`)},
+    {"xmpp", regexp.MustCompile(`(?i)^<\?xml.*jabber`)},
+    {"amqp", regexp.MustCompile(`^AMQP`)},
+}
+
+// Connection errors that mean there is nothing listening to grab a banner
+// from; a timed-out target would only time out again on the banner wait
+var unreachableErrors = []string{
+    "no such host", "connection refused", "network is unreachable", "no route to host", "invalid_url",
+    "context deadline exceeded", "i/o timeout", "Client.Timeout exceeded", "TLS handshake timeout",
+}
+
+// applyBanner grabs a banner for targets where HTTP probing failed and fills
+// the service and banner fields when something non-HTTP answered
+func (ac *AliveHTTPClient) applyBanner(ctx context.Context, result *Result, config *Config) {
+    if result.Status != 0 || result.Error == "" {
+        return
+    }
+    for _, unreachable := range unreachableErrors {
+        if strings.Contains(result.Error, unreachable) {
+            return
+        }
+    }
+
+    addr := bannerAddress(result.URL)
+    if addr == "" {
+        return
+    }
+
+    banner := grabBanner(ctx, addr, config.Timeout)
+    if banner == "" {
+        return
+    }
+    result.Service = matchService(banner)
+    result.Banner = cleanBanner(banner)
+}
+
+// bannerAddress derives host:port from a result URL, defaulting to the
+// scheme's port (80 for bare hosts)
+func bannerAddress(rawURL string) string {
+    if !strings.Contains(rawURL, "://") {
+        rawURL = "http://" + rawURL
+    }
+    u, err := url.Parse(rawURL)
+    if err != nil || u.Hostname() == "" {
+        return ""
+    }
+
+    port := u.Port()
+    if port == "" {
+        port = "80"
+        if u.Scheme == "https" {
+            port = "443"
+        }
+    }
+    return net.JoinHostPort(u.Hostname(), port)
+}
+
+// grabBanner reads what the service sends on connect; silent services get a
+// PING probe, which Redis and memcached answer
+func grabBanner(ctx context.Context, addr string, timeout time.Duration) string {
+    dialer := &net.Dialer{Timeout: timeout}
+    conn, err := dialer.DialContext(ctx, "tcp", addr)
+    if err != nil {
+        return ""
+    }
+    defer conn.Close()
+
+    buffer := make([]byte, BANNER_READ_SIZE)
+    conn.SetReadDeadline(time.Now().Add(BANNER_WAIT))
+    if n, _ := conn.Read(buffer); n > 0 {
+        return string(buffer[:n])
+    }
+
+    conn.SetDeadline(time.Now().Add(timeout))
+    if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+        return ""
+    }
+    n, _ := conn.Read(buffer)
+    return string(buffer[:n])
+}
+
+// matchService returns the first signature matching the banner, or "unknown"
+func matchService(banner string) string {
+    // Telnet opens with IAC option negotiation, raw bytes regexp can't match
+    if len(banner) > 1 && banner[0] == 0xff && banner[1] >= 0xfb {
+        return "telnet"
+    }
+    for _, sig := range serviceSignatures {
+        if sig.Pattern.MatchString(banner) {
+            return sig.Service
+        }
+    }
+    return "unknown"
+}
+
+// cleanBanner keeps printable ASCII on a single line and trims long banners
+func cleanBanner(banner string) string {
+    var b strings.Builder
+    for _, r := range banner {
+        switch {
+        case r == '\r' || r == '\n' || r == '\t':
+            b.WriteByte(' ')
+        case r >= 0x20 && r < 0x7f:
+            b.WriteRune(r)
+        }
+    }
+
+    cleaned := strings.TrimSpace(whitespaceRegex.ReplaceAllString(b.String(), " "))
+    if len(cleaned) > BANNER_MAX_LEN {
+        cleaned = cleaned[:BANNER_MAX_LEN] + "..."
+    }
+    return cleaned
+}
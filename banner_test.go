@@ -0,0 +1,76 @@
+package main
+
+import (
+    "context"
+    "net"
+    "testing"
+)
+
+// startBannerServer accepts connections and sends banner straight away
+func startBannerServer(t *testing.T, banner string) string {
+    t.Helper()
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { listener.Close() })
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            conn.Write([]byte(banner))
+            conn.Close()
+        }
+    }()
+    return listener.Addr().String()
+}
+
+func TestApplyBanner(t *testing.T) {
+    addr := startBannerServer(t, "SSH-2.0-OpenSSH_9.6\r\n")
+    config := defaultConfig()
+    client := NewAliveHTTPClient(config)
+    defer client.Close()
+
+    tests := []struct {
+        err         string
+        wantService string
+    }{
+        {`Get "http://` + addr + `": net/http: HTTP/1.x transport connection broken: malformed HTTP response "SSH-2.0-OpenSSH_9.6"`, "ssh"},
+        {`Head "http://` + addr + `": dial tcp ` + addr + `: i/o timeout`, ""},
+        {`Head "http://` + addr + `": context deadline exceeded (Client.Timeout exceeded while awaiting headers)`, ""},
+        {`Head "https://` + addr + `": net/http: TLS handshake timeout`, ""},
+        {`Head "http://` + addr + `": dial tcp ` + addr + `: connect: connection refused`, ""},
+    }
+    for _, tt := range tests {
+        result := &Result{URL: "http://" + addr, Error: tt.err}
+        client.applyBanner(context.Background(), result, config)
+        if result.Service != tt.wantService {
+            t.Errorf("error %q: service = %q, want %q", tt.err, result.Service, tt.wantService)
+        }
+    }
+}
+
+func TestMatchService(t *testing.T) {
+    tests := []struct {
+        banner string
+        want   string
+    }{
+        {"SSH-2.0-OpenSSH_9.6\r\n", "ssh"},
+        {"220 ProFTPD Server ready\r\n", "ftp"},
+        {"220 mail.example.com ESMTP Postfix\r\n", "smtp"},
+        {"+OK POP3 ready\r\n", "pop3"},
+        {"* OK IMAP4rev1 ready\r\n", "imap"},
+        {"-NOAUTH Authentication required.\r\n", "redis"},
+        {"ERROR\r\n", "memcached"},
+        {"RFB 003.008\n", "vnc"},
+        {"\xff\xfd\x18\xff\xfd\x20", "telnet"},
+        {"hello", "unknown"},
+    }
+    for _, tt := range tests {
+        if got := matchService(tt.banner); got != tt.want {
+            t.Errorf("matchService(%q) = %q, want %q", tt.banner, got, tt.want)
+        }
+    }
+}
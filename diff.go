@@ -0,0 +1,195 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+)
+
+// Diff classifications reported in Result.Diff
+const (
+    DiffNew     = "new"     // Alive now, absent or not alive in the baseline
+    DiffGone    = "gone"    // Alive in the baseline, not alive now
+    DiffChanged = "changed" // Alive in both with different fields
+
+    DiffMissingError = "missing_from_input" // Error of gone results that were not scanned
+)
+
+// Differ compares results against a previous scan
+type Differ struct {
+    byURL  map[string]*Result
+    byHost map[string]*Result // Fallback when only the scheme changed
+
+    mu   sync.Mutex
+    seen map[string]bool // Scheme-less URLs of the results applied so far
+
+    newCount     int64
+    goneCount    int64
+    changedCount int64
+}
+
+// LoadBaseline reads the previous scan: a scan ID from the result store, or
+// a JSON lines file written with -json
+func LoadBaseline(source string, store *Store) (*Differ, error) {
+    var results []*Result
+    var err error
+
+    if scanID, convErr := strconv.ParseInt(source, 10, 64); convErr == nil {
+        if store == nil {
+            return nil, fmt.Errorf("-diff with a scan ID requires -db")
+        }
+        results, err = store.LoadScan(scanID)
+    } else {
+        results, err = readResultsFile(source)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return newDiffer(results), nil
+}
+
+// newDiffer indexes baseline results by URL and by scheme-less host
+func newDiffer(results []*Result) *Differ {
+    d := &Differ{
+        byURL:  make(map[string]*Result, len(results)),
+        byHost: make(map[string]*Result, len(results)),
+        seen:   make(map[string]bool),
+    }
+    for _, result := range results {
+        d.byURL[result.URL] = result
+        host := stripScheme(result.URL)
+        if existing, ok := d.byHost[host]; !ok || (!existing.Alive && result.Alive) {
+            d.byHost[host] = result
+        }
+    }
+    return d
+}
+
+// readResultsFile parses results from a JSON lines file
+func readResultsFile(filename string) ([]*Result, error) {
+    file, err := os.Open(filename)
+    if err != nil {
+        return nil, fmt.Errorf("error opening diff baseline: %v", err)
+    }
+    defer file.Close()
+
+    var results []*Result
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+    lineNum := 0
+    for scanner.Scan() {
+        lineNum++
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" {
+            continue
+        }
+        result := &Result{}
+        if err := json.Unmarshal([]byte(line), result); err != nil {
+            return nil, fmt.Errorf("diff baseline line %d: %v", lineNum, err)
+        }
+        results = append(results, result)
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, fmt.Errorf("error reading diff baseline: %v", err)
+    }
+    return results, nil
+}
+
+// Apply sets Diff and Changes on a result; unchanged results keep Diff empty
+func (d *Differ) Apply(result *Result) {
+    host := stripScheme(result.URL)
+    d.mu.Lock()
+    d.seen[host] = true
+    d.mu.Unlock()
+
+    previous, ok := d.byURL[result.URL]
+    if !ok {
+        previous, ok = d.byHost[host]
+    }
+
+    switch {
+    case result.Alive && (!ok || !previous.Alive):
+        result.Diff = DiffNew
+        atomic.AddInt64(&d.newCount, 1)
+    case !result.Alive && ok && previous.Alive:
+        result.Diff = DiffGone
+        atomic.AddInt64(&d.goneCount, 1)
+    case result.Alive:
+        result.Changes = compareResults(previous, result)
+        if len(result.Changes) > 0 {
+            result.Diff = DiffChanged
+            atomic.AddInt64(&d.changedCount, 1)
+        }
+    }
+}
+
+// Missing returns the baseline's live results whose target got no result in
+// this run, e.g. because it was removed from the input. They keep the
+// baseline fields and are marked gone; call it once a scan has completed
+func (d *Differ) Missing() []*Result {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+
+    var missing []*Result
+    for _, previous := range d.byURL {
+        if !previous.Alive || d.seen[stripScheme(previous.URL)] {
+            continue
+        }
+        gone := *previous
+        gone.Alive = false
+        gone.Verified = false
+        gone.Error = DiffMissingError
+        gone.Diff = DiffGone
+        gone.Changes = nil
+        missing = append(missing, &gone)
+    }
+    sort.Slice(missing, func(i, j int) bool {
+        return missing[i].URL < missing[j].URL
+    })
+    atomic.AddInt64(&d.goneCount, int64(len(missing)))
+    return missing
+}
+
+// String summarises the differences found
+func (d *Differ) String() string {
+    return fmt.Sprintf("Diff: %d new | %d gone | %d changed",
+        atomic.LoadInt64(&d.newCount),
+        atomic.LoadInt64(&d.goneCount),
+        atomic.LoadInt64(&d.changedCount))
+}
+
+// compareResults lists the tracked fields that differ between two live results
+func compareResults(previous, current *Result) []string {
+    var changes []string
+    change := func(field, before, after string) {
+        if before != after {
+            changes = append(changes, fmt.Sprintf("%s: %s -> %s", field, quoteEmpty(before), quoteEmpty(after)))
+        }
+    }
+
+    change("url", previous.URL, current.URL)
+    change("status_code", strconv.Itoa(previous.Status), strconv.Itoa(current.Status))
+    change("title", previous.Title, current.Title)
+    change("server", previous.Server, current.Server)
+    change("content_length", strconv.FormatInt(previous.Length, 10), strconv.FormatInt(current.Length, 10))
+    change("redirect", previous.Redirect, current.Redirect)
+    // Baselines from plain HTTP or older runs have no fingerprint to compare
+    if previous.CertSHA256 != "" && current.CertSHA256 != "" {
+        change("cert_sha256", previous.CertSHA256, current.CertSHA256)
+    }
+    return changes
+}
+
+// quoteEmpty makes empty values visible in change descriptions
+func quoteEmpty(value string) string {
+    if value == "" {
+        return `""`
+    }
+    return value
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+    "bytes"
+    "testing"
+)
+
+func TestDifferApplyAndMissing(t *testing.T) {
+    d := newDiffer([]*Result{
+        {URL: "https://kept.example.com", Status: 200, Title: "Home", Alive: true},
+        {URL: "https://down.example.com", Status: 200, Alive: true},
+        {URL: "http://rescheme.example.com", Status: 200, Alive: true},
+        {URL: "https://removed.example.com", Status: 200, Title: "Old", Alive: true},
+        {URL: "https://dead.example.com", Error: "no_response"},
+    })
+
+    tests := []struct {
+        result *Result
+        want   string
+    }{
+        {&Result{URL: "https://kept.example.com", Status: 200, Title: "Home", Alive: true}, ""},
+        {&Result{URL: "https://down.example.com", Error: "connection_failed: refused"}, DiffGone},
+        {&Result{URL: "https://rescheme.example.com", Status: 200, Alive: true}, DiffChanged},
+        {&Result{URL: "https://dead.example.com", Status: 200, Alive: true}, DiffNew},
+        {&Result{URL: "https://fresh.example.com", Status: 200, Alive: true}, DiffNew},
+    }
+    for _, tt := range tests {
+        d.Apply(tt.result)
+        if tt.result.Diff != tt.want {
+            t.Errorf("%s: diff = %q, want %q", tt.result.URL, tt.result.Diff, tt.want)
+        }
+    }
+
+    // Only the live baseline host without a result in this run
+    missing := d.Missing()
+    if len(missing) != 1 {
+        t.Fatalf("missing = %d results, want 1", len(missing))
+    }
+    gone := missing[0]
+    if gone.URL != "https://removed.example.com" || gone.Diff != DiffGone || gone.Alive || gone.Error != DiffMissingError || gone.Title != "Old" {
+        t.Errorf("missing result = %+v", gone)
+    }
+    if got, want := d.String(), "Diff: 2 new | 2 gone | 1 changed"; got != want {
+        t.Errorf("summary = %q, want %q", got, want)
+    }
+}
+
+func TestGoneTemplates(t *testing.T) {
+    gone := &Result{URL: "https://removed.example.com", Diff: DiffGone, Error: DiffMissingError}
+    tests := []struct {
+        template   string
+        showFailed bool
+        want       string
+    }{
+        {TemplateClean, false, ""},
+        {TemplateClean, true, "https://removed.example.com [GONE]\n"},
+        {TemplateDetailed, false, "https://removed.example.com [GONE] [missing_from_input]\n"},
+    }
+    for _, tt := range tests {
+        config := defaultConfig()
+        config.ShowFailed = tt.showFailed
+        var buf bytes.Buffer
+        tf, err := NewTemplateFormatter(&buf, tt.template, config)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if err := tf.Format(gone); err != nil {
+            t.Fatal(err)
+        }
+        if buf.String() != tt.want {
+            t.Errorf("show failed %t: got %q, want %q", tt.showFailed, buf.String(), tt.want)
+        }
+    }
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "flag"
+    "fmt"
+    "net/http"
+    "os"
+    "os/signal"
+    "runtime"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "syscall"
+    "time"
+)
+
+const (
+    DEFAULT_BATCH_SIZE    = 100              // Targets handed to a worker per lease
+    DEFAULT_LEASE_TIMEOUT = 2 * time.Minute  // Lease lifetime without renewal
+    WORKER_POLL_INTERVAL  = 2 * time.Second  // Wait when every batch is leased
+    WORKER_MAX_RETRIES    = 5                // Consecutive coordinator errors before a worker gives up
+    COORDINATOR_LINGER    = 2 * WORKER_POLL_INTERVAL // Keep answering so workers learn the scan is over
+)
+
+// Batch states
+const (
+    BatchPending = "pending"
+    BatchLeased  = "leased"
+    BatchDone    = "done"
+)
+
+// Lease is a batch handed to a worker
+type Lease struct {
+    ID           string   `json:"id"`
+    Batch        int      `json:"batch"`
+    Targets      []Target `json:"targets"`
+    Config       *Config  `json:"config"`
+    LeaseSeconds int      `json:"lease_seconds"`
+}
+
+// BatchStats are the worker's counters for one batch
+type BatchStats struct {
+    Checked  uint64 `json:"checked"`
+    Alive    uint64 `json:"alive"`
+    Verified uint64 `json:"verified"`
+    Errors   uint64 `json:"errors"`
+}
+
+// BatchReport is what a worker sends back for a completed lease
+type BatchReport struct {
+    Worker  string     `json:"worker"`
+    Results []*Result  `json:"results"`
+    Stats   BatchStats `json:"stats"`
+}
+
+// batch is one shard of the input
+type batch struct {
+    id       int
+    targets  []Target
+    state    string
+    lease    string
+    worker   string
+    expires  time.Time
+    attempts int
+}
+
+// Coordinator shards targets into batches, leases them to workers and merges
+// their results and stats
+type Coordinator struct {
+    config       *Config
+    stats        *Stats
+    leaseTimeout time.Duration
+    handle       func(*Result)
+
+    output  sync.Mutex     // Serialises handle
+    merging sync.WaitGroup // Reports being handled
+
+    mu        sync.Mutex
+    batches   []*batch
+    leases    map[string]*batch // Every lease issued, kept so late reports still count
+    remaining int
+    done      chan struct{}
+}
+
+// NewCoordinator splits targets into batches of batchSize, none leased yet
+func NewCoordinator(targets []Target, config *Config, stats *Stats, batchSize int,
+    leaseTimeout time.Duration, handle func(*Result)) *Coordinator {
+    if batchSize < 1 {
+        batchSize = DEFAULT_BATCH_SIZE
+    }
+    c := &Coordinator{
+        config:       config,
+        stats:        stats,
+        leaseTimeout: leaseTimeout,
+        handle:       handle,
+        leases:       make(map[string]*batch),
+        done:         make(chan struct{}),
+    }
+    for start := 0; start < len(targets); start += batchSize {
+        end := start + batchSize
+        if end > len(targets) {
+            end = len(targets)
+        }
+        c.batches = append(c.batches, &batch{id: len(c.batches), targets: targets[start:end], state: BatchPending})
+    }
+    c.remaining = len(c.batches)
+    if c.remaining == 0 {
+        close(c.done)
+    }
+    return c
+}
+
+// Handler returns the worker API, guarded by token when it is set
+func (c *Coordinator) Handler(token string) http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc("POST /lease", c.handleLease)
+    mux.HandleFunc("POST /lease/{id}/renew", c.handleRenew)
+    mux.HandleFunc("POST /lease/{id}/complete", c.handleComplete)
+    return requireToken(token, mux)
+}
+
+// runCoordinator serves batches to workers until every batch is done or ctx
+// is cancelled; results are passed to handle one at a time
+func runCoordinator(ctx context.Context, addr, token string, targets []Target, config *Config, stats *Stats,
+    batchSize int, leaseTimeout time.Duration, handle func(*Result)) error {
+    c := NewCoordinator(targets, config, stats, batchSize, leaseTimeout, handle)
+    httpServer := &http.Server{
+        Addr:              addr,
+        Handler:           c.Handler(token),
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+
+    serveErr := make(chan error, 1)
+    go func() {
+        serveErr <- httpServer.ListenAndServe()
+    }()
+    if !config.Silent {
+        fmt.Fprintf(os.Stderr, "Coordinator listening on %s: %d targets in %d batches\n", addr, len(targets), len(c.batches))
+    }
+    progressCtx, stopProgress := context.WithCancel(ctx)
+    defer stopProgress()
+    go displayProgress(progressCtx, stats, config)
+
+    select {
+    case err := <-serveErr:
+        if !errors.Is(err, http.ErrServerClosed) {
+            return fmt.Errorf("error starting coordinator: %v", err)
+        }
+        return nil
+    case <-ctx.Done():
+    case <-c.done:
+        stopProgress()
+        // Workers polling for more work are told the scan is over
+        select {
+        case <-time.After(COORDINATOR_LINGER):
+        case <-ctx.Done():
+        }
+    }
+
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    httpServer.Shutdown(shutdownCtx)
+    return nil
+}
+
+// handleLease hands the next pending batch to a worker: 204 when every batch
+// is leased, 410 when the scan is finished
+func (c *Coordinator) handleLease(w http.ResponseWriter, r *http.Request) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if c.remaining == 0 {
+        writeError(w, http.StatusGone, "scan finished")
+        return
+    }
+    c.expireLeases()
+
+    var next *batch
+    for _, b := range c.batches {
+        if b.state == BatchPending {
+            next = b
+            break
+        }
+    }
+    if next == nil {
+        w.WriteHeader(http.StatusNoContent)
+        return
+    }
+
+    next.state = BatchLeased
+    next.lease = newJobID()
+    next.worker = r.URL.Query().Get("worker")
+    next.expires = time.Now().Add(c.leaseTimeout)
+    next.attempts++
+    c.leases[next.lease] = next
+
+    writeJSON(w, http.StatusOK, &Lease{
+        ID:           next.lease,
+        Batch:        next.id,
+        Targets:      next.targets,
+        Config:       c.config,
+        LeaseSeconds: int(c.leaseTimeout.Seconds()),
+    })
+}
+
+// expireLeases puts batches whose lease ran out back in the queue; callers hold c.mu
+func (c *Coordinator) expireLeases() {
+    now := time.Now()
+    for _, b := range c.batches {
+        if b.state == BatchLeased && now.After(b.expires) {
+            if !c.config.Silent {
+                fmt.Fprintf(os.Stderr, "\r\033[KLease for batch %d expired (worker %s, attempt %d), re-assigning\n", b.id, b.worker, b.attempts)
+            }
+            b.state = BatchPending
+        }
+    }
+}
+
+// handleRenew extends a lease while the worker is still scanning
+func (c *Coordinator) handleRenew(w http.ResponseWriter, r *http.Request) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    id := r.PathValue("id")
+    b := c.leases[id]
+    if b == nil || b.state != BatchLeased || b.lease != id {
+        writeError(w, http.StatusGone, "lease expired or unknown")
+        return
+    }
+    b.expires = time.Now().Add(c.leaseTimeout)
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// handleComplete merges a worker's results; late reports for a batch that
+// was re-assigned are still accepted if no other worker finished it first
+func (c *Coordinator) handleComplete(w http.ResponseWriter, r *http.Request) {
+    var report BatchReport
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MAX_REQUEST_BODY)).Decode(&report); err != nil {
+        writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid batch report: %v", err))
+        return
+    }
+
+    c.mu.Lock()
+    b := c.leases[r.PathValue("id")]
+    if b == nil || b.state == BatchDone {
+        c.mu.Unlock()
+        w.WriteHeader(http.StatusNoContent) // Duplicate or unknown, nothing to merge
+        return
+    }
+    b.state = BatchDone
+    c.remaining--
+    finished := c.remaining == 0
+    c.merging.Add(1)
+    c.mu.Unlock()
+
+    // Results are handled outside c.mu, which a slow -db or notification
+    // would hold up for every lease and renewal, but one batch at a time so
+    // output stays single-threaded
+    atomic.AddUint64(&c.stats.checked, report.Stats.Checked)
+    atomic.AddUint64(&c.stats.alive, report.Stats.Alive)
+    atomic.AddUint64(&c.stats.verified, report.Stats.Verified)
+    atomic.AddUint64(&c.stats.errors, report.Stats.Errors)
+    c.output.Lock()
+    for _, result := range report.Results {
+        c.handle(result)
+    }
+    c.output.Unlock()
+    c.merging.Done()
+
+    if finished {
+        c.merging.Wait() // Other batches may still be writing their results
+        close(c.done)
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// Worker leases batches from a coordinator and scans them locally
+type Worker struct {
+    coordinator string
+    token       string
+    name        string
+    workers     int     // Local override of the coordinator's worker count
+    rate        float64 // Local override of the coordinator's rate
+    http        *http.Client
+}
+
+// runWorker parses the worker flags and scans batches until the coordinator
+// reports the scan finished
+func runWorker(args []string) {
+    hostname, _ := os.Hostname()
+    fs := flag.NewFlagSet("worker", flag.ExitOnError)
+    coordinator := fs.String("coordinator", "", "Coordinator URL, e.g. http://10.0.0.1:9000")
+    token := fs.String("token", os.Getenv("ALIVEHUNTER_API_TOKEN"), "Coordinator token (default: $ALIVEHUNTER_API_TOKEN)")
+    name := fs.String("name", fmt.Sprintf("%s-%d", hostname, os.Getpid()), "Worker name shown by the coordinator")
+    workers := fs.Int("t", 0, "Number of threads (default: coordinator's setting)")
+    rate := fs.Float64("rate", 0, "Requests per second (default: coordinator's setting)")
+    fs.Parse(args)
+
+    if *coordinator == "" {
+        fmt.Fprintf(os.Stderr, "Usage: %s worker -coordinator http://host:port [-token X]\n", os.Args[0])
+        os.Exit(1)
+    }
+    if !strings.Contains(*coordinator, "://") {
+        *coordinator = "http://" + *coordinator
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    sigChan := make(chan os.Signal, 1)
+    signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+    go func() {
+        <-sigChan
+        fmt.Fprintf(os.Stderr, "\nReceived interrupt, abandoning current batch...\n")
+        cancel()
+    }()
+
+    worker := &Worker{
+        coordinator: strings.TrimRight(*coordinator, "/"),
+        token:       *token,
+        name:        *name,
+        workers:     *workers,
+        rate:        *rate,
+        http:        &http.Client{Timeout: 30 * time.Second},
+    }
+    if err := worker.Run(ctx); err != nil {
+        fmt.Fprintf(os.Stderr, "Worker stopped: %v\n", err)
+        os.Exit(1)
+    }
+}
+
+// Run leases and scans batches until the scan is finished or ctx is cancelled
+func (wk *Worker) Run(ctx context.Context) error {
+    failures := 0
+    for ctx.Err() == nil {
+        lease, status, err := wk.lease(ctx)
+        switch {
+        case status == http.StatusUnauthorized:
+            return err // Retrying with the same token cannot succeed
+        case err != nil:
+            failures++
+            if failures >= WORKER_MAX_RETRIES {
+                return err
+            }
+            fmt.Fprintf(os.Stderr, "Coordinator unavailable (%v), retrying\n", err)
+            sleepContext(ctx, WORKER_POLL_INTERVAL*time.Duration(failures))
+            continue
+        case status == http.StatusGone:
+            fmt.Fprintf(os.Stderr, "Scan finished, worker exiting\n")
+            return nil
+        case status == http.StatusNoContent:
+            failures = 0
+            sleepContext(ctx, WORKER_POLL_INTERVAL)
+            continue
+        }
+        failures = 0
+
+        report := wk.scan(ctx, lease)
+        if ctx.Err() != nil {
+            return nil // Lease expires and the batch is re-assigned
+        }
+        if err := wk.complete(ctx, lease, report); err != nil {
+            fmt.Fprintf(os.Stderr, "Error reporting batch %d: %v\n", lease.Batch, err)
+        }
+    }
+    return nil
+}
+
+// scan runs one batch, renewing the lease until it is done
+func (wk *Worker) scan(ctx context.Context, lease *Lease) *BatchReport {
+    config := lease.Config
+    config.Silent = true
+    config.Watch = false
+    if wk.workers > 0 {
+        config.Workers = wk.workers
+    }
+    if wk.rate > 0 {
+        config.Rate = wk.rate
+    }
+    if maxWorkers := runtime.NumCPU() * 50; config.Workers > maxWorkers {
+        config.Workers = maxWorkers
+    }
+    fmt.Fprintf(os.Stderr, "Scanning batch %d: %d targets\n", lease.Batch, len(lease.Targets))
+
+    renewCtx, stopRenew := context.WithCancel(ctx)
+    defer stopRenew()
+    go wk.renew(renewCtx, lease)
+
+    stats := &Stats{started: time.Now(), totalUrls: int64(len(lease.Targets))}
+    report := &BatchReport{Worker: wk.name}
+    client := NewAliveHTTPClient(config)
+    defer client.Close()
+    runScan(ctx, lease.Targets, client, config, stats, func(result *Result) {
+        report.Results = append(report.Results, result)
+    })
+    report.Stats = BatchStats{
+        Checked:  atomic.LoadUint64(&stats.checked),
+        Alive:    atomic.LoadUint64(&stats.alive),
+        Verified: atomic.LoadUint64(&stats.verified),
+        Errors:   atomic.LoadUint64(&stats.errors),
+    }
+    return report
+}
+
+// renew keeps the lease alive at a third of its lifetime
+func (wk *Worker) renew(ctx context.Context, lease *Lease) {
+    interval := time.Duration(lease.LeaseSeconds) * time.Second / 3
+    if interval < time.Second {
+        interval = time.Second
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            resp, err := wk.post(ctx, "/lease/"+lease.ID+"/renew", nil)
+            if err != nil {
+                continue
+            }
+            resp.Body.Close()
+            if resp.StatusCode == http.StatusGone {
+                fmt.Fprintf(os.Stderr, "Lease for batch %d expired, finishing anyway\n", lease.Batch)
+                return
+            }
+        }
+    }
+}
+
+// lease asks the coordinator for a batch
+func (wk *Worker) lease(ctx context.Context) (*Lease, int, error) {
+    resp, err := wk.post(ctx, "/lease?worker="+wk.name, nil)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer resp.Body.Close()
+
+    switch resp.StatusCode {
+    case http.StatusOK:
+        lease := &Lease{}
+        if err := json.NewDecoder(resp.Body).Decode(lease); err != nil {
+            return nil, 0, fmt.Errorf("invalid lease: %v", err)
+        }
+        return lease, resp.StatusCode, nil
+    case http.StatusNoContent, http.StatusGone:
+        return nil, resp.StatusCode, nil
+    default:
+        return nil, resp.StatusCode, fmt.Errorf("coordinator returned %s", resp.Status)
+    }
+}
+
+// complete sends the batch results, retrying transient failures
+func (wk *Worker) complete(ctx context.Context, lease *Lease, report *BatchReport) error {
+    payload, err := json.Marshal(report)
+    if err != nil {
+        return err
+    }
+
+    var lastErr error
+    for attempt := 1; attempt <= WORKER_MAX_RETRIES && ctx.Err() == nil; attempt++ {
+        resp, err := wk.post(ctx, "/lease/"+lease.ID+"/complete", payload)
+        if err == nil {
+            resp.Body.Close()
+            if resp.StatusCode < 300 {
+                return nil
+            }
+            err = fmt.Errorf("coordinator returned %s", resp.Status)
+            if resp.StatusCode < 500 {
+                return err
+            }
+        }
+        lastErr = err
+        sleepContext(ctx, WORKER_POLL_INTERVAL*time.Duration(attempt))
+    }
+    return lastErr
+}
+
+// post sends an authenticated POST to the coordinator
+func (wk *Worker) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+    req, err := http.NewRequestWithContext(ctx, "POST", wk.coordinator+path, bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+    if wk.token != "" {
+        req.Header.Set("Authorization", "Bearer "+wk.token)
+    }
+    return wk.http.Do(req)
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) {
+    select {
+    case <-ctx.Done():
+    case <-time.After(d):
+    }
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sort"
+    "sync"
+    "testing"
+    "time"
+)
+
+// coordinatorCall posts to the coordinator API and decodes a lease when one is returned
+func coordinatorCall(t *testing.T, api, path string, body interface{}) (int, *Lease) {
+    t.Helper()
+    var payload []byte
+    if body != nil {
+        payload, _ = json.Marshal(body)
+    }
+    resp, err := http.Post(api+path, "application/json", bytes.NewReader(payload))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return resp.StatusCode, nil
+    }
+    lease := &Lease{}
+    if err := json.NewDecoder(resp.Body).Decode(lease); err != nil {
+        t.Fatal(err)
+    }
+    return resp.StatusCode, lease
+}
+
+// silentConfig is the default config without progress or lease messages
+func silentConfig() *Config {
+    config := defaultConfig()
+    config.Silent = true
+    return config
+}
+
+func TestCoordinatorLeaseExpiryAndLateCompletion(t *testing.T) {
+    var handled []string
+    c := NewCoordinator(newTargets([]string{"a.example.com", "b.example.com"}), silentConfig(), &Stats{}, 10,
+        50*time.Millisecond, func(result *Result) { handled = append(handled, result.URL) })
+    api := httptest.NewServer(c.Handler(""))
+    defer api.Close()
+
+    code, first := coordinatorCall(t, api.URL, "/lease?worker=one", nil)
+    if code != http.StatusOK || len(first.Targets) != 2 {
+        t.Fatalf("first lease: status %d, lease %+v", code, first)
+    }
+    if code, _ := coordinatorCall(t, api.URL, "/lease?worker=two", nil); code != http.StatusNoContent {
+        t.Fatalf("lease while batch is leased: status %d, want %d", code, http.StatusNoContent)
+    }
+    if code, _ := coordinatorCall(t, api.URL, "/lease/"+first.ID+"/renew", nil); code != http.StatusNoContent {
+        t.Fatalf("renew of a live lease: status %d, want %d", code, http.StatusNoContent)
+    }
+
+    // The lease runs out and the batch goes to the next worker
+    time.Sleep(100 * time.Millisecond)
+    code, second := coordinatorCall(t, api.URL, "/lease?worker=two", nil)
+    if code != http.StatusOK || second.Batch != first.Batch || second.ID == first.ID {
+        t.Fatalf("re-assigned lease: status %d, lease %+v", code, second)
+    }
+    if code, _ := coordinatorCall(t, api.URL, "/lease/"+first.ID+"/renew", nil); code != http.StatusGone {
+        t.Errorf("renew of an expired lease: status %d, want %d", code, http.StatusGone)
+    }
+
+    // The original worker finishes first: its late report is merged
+    late := &BatchReport{Worker: "one", Results: []*Result{{URL: "https://a.example.com"}}, Stats: BatchStats{Checked: 2, Alive: 1}}
+    if code, _ := coordinatorCall(t, api.URL, "/lease/"+first.ID+"/complete", late); code != http.StatusNoContent {
+        t.Fatalf("late completion: status %d", code)
+    }
+    select {
+    case <-c.done:
+    default:
+        t.Fatal("scan not finished after the only batch completed")
+    }
+
+    // Reports for a finished batch are ignored, from either lease
+    dup := &BatchReport{Worker: "two", Results: []*Result{{URL: "https://b.example.com"}}, Stats: BatchStats{Checked: 2}}
+    for _, id := range []string{second.ID, first.ID} {
+        if code, _ := coordinatorCall(t, api.URL, "/lease/"+id+"/complete", dup); code != http.StatusNoContent {
+            t.Errorf("duplicate completion: status %d", code)
+        }
+    }
+    if len(handled) != 1 || handled[0] != "https://a.example.com" {
+        t.Errorf("handled = %v, want only the first report", handled)
+    }
+    if c.stats.checked != 2 || c.stats.alive != 1 {
+        t.Errorf("stats checked %d alive %d, want 2 and 1", c.stats.checked, c.stats.alive)
+    }
+    if code, _ := coordinatorCall(t, api.URL, "/lease?worker=three", nil); code != http.StatusGone {
+        t.Errorf("lease after the scan: status %d, want %d", code, http.StatusGone)
+    }
+}
+
+func TestCoordinatorRejectsBadRequests(t *testing.T) {
+    c := NewCoordinator(newTargets([]string{"a.example.com"}), silentConfig(), &Stats{}, 10, time.Minute, func(*Result) {})
+    api := httptest.NewServer(c.Handler("secret"))
+    defer api.Close()
+
+    if code, _ := coordinatorCall(t, api.URL, "/lease", nil); code != http.StatusUnauthorized {
+        t.Errorf("lease without token: status %d, want %d", code, http.StatusUnauthorized)
+    }
+
+    api2 := httptest.NewServer(c.Handler(""))
+    defer api2.Close()
+    if code, _ := coordinatorCall(t, api2.URL, "/lease/unknown/renew", nil); code != http.StatusGone {
+        t.Errorf("renew of an unknown lease: status %d, want %d", code, http.StatusGone)
+    }
+    resp, err := http.Post(api2.URL+"/lease/unknown/complete", "application/json", bytes.NewBufferString("{"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusBadRequest {
+        t.Errorf("invalid report: status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+    }
+}
+
+func TestWorkerRun(t *testing.T) {
+    target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer target.Close()
+
+    var urls []string
+    for i := 0; i < 5; i++ {
+        urls = append(urls, fmt.Sprintf("%s/%d", target.URL, i))
+    }
+    config := silentConfig()
+    config.ProbeStrategy = ProbeHTTP
+    config.Timeout = 5 * time.Second
+
+    var mu sync.Mutex
+    var handled []string
+    c := NewCoordinator(newTargets(urls), config, &Stats{}, 2, time.Minute, func(result *Result) {
+        mu.Lock()
+        handled = append(handled, result.URL)
+        mu.Unlock()
+    })
+    api := httptest.NewServer(c.Handler("secret"))
+    defer api.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    // A wrong token stops the worker instead of retrying
+    wrong := &Worker{coordinator: api.URL, token: "wrong", name: "w0", http: &http.Client{}}
+    if err := wrong.Run(ctx); err == nil {
+        t.Fatal("worker with a wrong token returned no error")
+    }
+
+    worker := &Worker{coordinator: api.URL, token: "secret", name: "w1", workers: 2, http: &http.Client{}}
+    if err := worker.Run(ctx); err != nil {
+        t.Fatalf("worker: %v", err)
+    }
+    select {
+    case <-c.done:
+    default:
+        t.Fatal("worker returned before every batch was done")
+    }
+
+    mu.Lock()
+    defer mu.Unlock()
+    sort.Strings(handled)
+    if len(handled) != len(urls) {
+        t.Fatalf("handled %d results, want %d: %v", len(handled), len(urls), handled)
+    }
+    for i, url := range urls {
+        if handled[i] != url {
+            t.Errorf("result %d = %s, want %s", i, handled[i], url)
+        }
+    }
+    if c.stats.checked != uint64(len(urls)) || c.stats.alive != uint64(len(urls)) {
+        t.Errorf("stats checked %d alive %d, want %d", c.stats.checked, c.stats.alive, len(urls))
+    }
+}
+
+func TestCoordinatorSlowHandleDoesNotBlockLeases(t *testing.T) {
+    release := make(chan struct{})
+    c := NewCoordinator(newTargets([]string{"a.example.com", "b.example.com"}), silentConfig(), &Stats{}, 1,
+        time.Minute, func(*Result) { <-release })
+    api := httptest.NewServer(c.Handler(""))
+    defer api.Close()
+
+    _, first := coordinatorCall(t, api.URL, "/lease?worker=one", nil)
+    _, second := coordinatorCall(t, api.URL, "/lease?worker=two", nil)
+    if first == nil || second == nil {
+        t.Fatal("expected two leases")
+    }
+
+    // The first report's results are stuck in handle, e.g. behind a webhook
+    completed := make(chan int, 1)
+    go func() {
+        report := &BatchReport{Results: []*Result{{URL: "https://a.example.com"}}}
+        code, _ := coordinatorCall(t, api.URL, "/lease/"+first.ID+"/complete", report)
+        completed <- code
+    }()
+    time.Sleep(50 * time.Millisecond)
+
+    start := time.Now()
+    if code, _ := coordinatorCall(t, api.URL, "/lease/"+second.ID+"/renew", nil); code != http.StatusNoContent {
+        t.Errorf("renew: status %d, want %d", code, http.StatusNoContent)
+    }
+    if code, _ := coordinatorCall(t, api.URL, "/lease?worker=three", nil); code != http.StatusNoContent {
+        t.Errorf("lease: status %d, want %d", code, http.StatusNoContent)
+    }
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("lease calls took %v while results were being handled", elapsed)
+    }
+
+    close(release)
+    if code := <-completed; code != http.StatusNoContent {
+        t.Errorf("complete: status %d", code)
+    }
+    coordinatorCall(t, api.URL, "/lease/"+second.ID+"/complete", &BatchReport{})
+    select {
+    case <-c.done:
+    case <-time.After(5 * time.Second):
+        t.Fatal("scan not finished after both batches completed")
+    }
+}
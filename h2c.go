@@ -0,0 +1,194 @@
+package main
+
+import (
+    "bufio"
+    "context"
+    "crypto/tls"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+
+    "golang.org/x/net/http2"
+)
+
+const (
+    ProtoH2C = "h2c"
+
+    // Base64url SETTINGS payload sent with Upgrade: h2c (as used by curl)
+    H2C_SETTINGS = "AAMAAABkAARAAAAAAAIAAAAA"
+)
+
+// H2CReport records cleartext HTTP/2 support for one target
+type H2CReport struct {
+    PriorKnowledge bool `json:"prior_knowledge"`          // Speaks HTTP/2 without negotiation
+    Upgrade        bool `json:"upgrade"`                  // Accepts Upgrade: h2c (101 Switching Protocols)
+    UpgradeStatus  int  `json:"upgrade_status,omitempty"` // Status returned to the upgrade request
+}
+
+// newH2CClient creates an HTTP/2 client that speaks h2c with prior knowledge
+func newH2CClient(config *Config) *http.Client {
+    return &http.Client{
+        Transport: &http2.Transport{
+            AllowHTTP: true,
+            DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+                dialer := &net.Dialer{Timeout: config.Timeout}
+                return dialer.DialContext(ctx, network, addr)
+            },
+        },
+        Timeout: config.Timeout,
+        CheckRedirect: func(req *http.Request, via []*http.Request) error {
+            return http.ErrUseLastResponse
+        },
+    }
+}
+
+// applyH2C checks h2c support for a result; plain-HTTP targets that were not
+// alive over HTTP/1.1 but answer h2c are turned into live results
+func (ac *AliveHTTPClient) applyH2C(ctx context.Context, result *Result, config *Config) {
+    if ac.h2cclient == nil {
+        return
+    }
+
+    report := &H2CReport{}
+    fullURL := result.URL
+    if result.Status == 0 {
+        // CheckURL got no answer, retry the plain HTTP endpoint as h2c, but
+        // only where the probe strategy and input scheme allow plain HTTP
+        candidates := probeURLs(result.URL, config.ProbeStrategy)
+        fullURL = candidates[0]
+        for _, candidate := range candidates {
+            if strings.HasPrefix(candidate, "http://") {
+                fullURL = candidate
+                break
+            }
+        }
+    }
+
+    // Prior knowledge only makes sense in cleartext, over TLS it is plain h2
+    if strings.HasPrefix(fullURL, "http://") {
+        start := time.Now()
+        withBody := config.Match.needsBody() || config.Filter.needsBody()
+        if resp, body, err := ac.h2cPriorKnowledge(ctx, fullURL, withBody); err == nil {
+            report.PriorKnowledge = true
+            result.Protocols = append(result.Protocols, ProtoH2C)
+
+            // HTTP/1.1 misjudged the service, use the h2c answer instead if it
+            // passes the same status code and -m*/-f* gate as probeURL
+            if !result.Alive && result.Error != "false_positive_detected" {
+                h2cResult := *result
+                h2cResult.URL = fullURL
+                h2cResult.Status = resp.StatusCode
+                h2cResult.ResponseTime = time.Since(start)
+                h2cResult.Server = resp.Header.Get("Server")
+                h2cResult.Headers = captureHeaders(resp.Header, config.Headers)
+                h2cResult.Length = 0
+                if resp.ContentLength > 0 {
+                    h2cResult.Length = resp.ContentLength
+                }
+                if withBody {
+                    h2cResult.Length = int64(len(body))
+                    countBody(&h2cResult, body)
+                }
+                if responseMatches(resp, body, &h2cResult, config) {
+                    h2cResult.Alive = true
+                    h2cResult.Error = ""
+                    *result = h2cResult
+                }
+            }
+        }
+    }
+
+    // Upgrade over TLS too: front-ends forwarding it are h2c smuggling candidates
+    if status, err := ac.h2cUpgrade(ctx, fullURL, config.Timeout); err == nil {
+        report.UpgradeStatus = status
+        report.Upgrade = status == http.StatusSwitchingProtocols
+    }
+
+    result.H2C = report
+}
+
+// h2cPriorKnowledge sends a request as HTTP/2 over cleartext TCP: HEAD, or
+// GET returning the body when body matchers need it
+func (ac *AliveHTTPClient) h2cPriorKnowledge(ctx context.Context, fullURL string, withBody bool) (*http.Response, []byte, error) {
+    method := "HEAD"
+    if withBody {
+        method = "GET"
+    }
+    req, err := ac.createRequest(ctx, method, fullURL, RequestTypeCheck)
+    if err != nil {
+        return nil, nil, err
+    }
+    resp, err := ac.h2cclient.Do(req)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer resp.Body.Close()
+
+    var body []byte
+    if withBody {
+        if body, err = io.ReadAll(io.LimitReader(resp.Body, MATCH_BODY_SIZE)); err != nil {
+            return nil, nil, err
+        }
+    }
+    return resp, body, nil
+}
+
+// h2cUpgrade sends an HTTP/1.1 request with Upgrade: h2c and returns the status
+func (ac *AliveHTTPClient) h2cUpgrade(ctx context.Context, fullURL string, timeout time.Duration) (int, error) {
+    u, err := url.Parse(fullURL)
+    if err != nil {
+        return 0, err
+    }
+
+    port := u.Port()
+    if port == "" {
+        port = "80"
+        if u.Scheme == "https" {
+            port = "443"
+        }
+    }
+    addr := net.JoinHostPort(u.Hostname(), port)
+
+    dialer := &net.Dialer{Timeout: timeout}
+    var conn net.Conn
+    if u.Scheme == "https" {
+        conn, err = (&tls.Dialer{
+            NetDialer: dialer,
+            Config: &tls.Config{
+                InsecureSkipVerify: true,
+                ServerName:         u.Hostname(),
+                NextProtos:         []string{"http/1.1"},
+            },
+        }).DialContext(ctx, "tcp", addr)
+    } else {
+        conn, err = dialer.DialContext(ctx, "tcp", addr)
+    }
+    if err != nil {
+        return 0, err
+    }
+    defer conn.Close()
+    conn.SetDeadline(time.Now().Add(timeout))
+
+    request := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\nUser-Agent: AliveHunter/%s\r\nAccept: */*\r\n"+
+        "Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: %s\r\n\r\n",
+        u.RequestURI(), u.Host, VERSION, H2C_SETTINGS)
+    if _, err := conn.Write([]byte(request)); err != nil {
+        return 0, err
+    }
+
+    resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+    if err != nil {
+        return 0, err
+    }
+    resp.Body.Close()
+
+    // A 101 only counts when the server switches to h2c
+    if resp.StatusCode == http.StatusSwitchingProtocols && !strings.EqualFold(resp.Header.Get("Upgrade"), ProtoH2C) {
+        return resp.StatusCode, fmt.Errorf("unexpected upgrade protocol %q", resp.Header.Get("Upgrade"))
+    }
+    return resp.StatusCode, nil
+}
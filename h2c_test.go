@@ -0,0 +1,123 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "log"
+    "net"
+    "net/http"
+    "strings"
+    "testing"
+
+    "golang.org/x/net/http2"
+)
+
+// startH2CServer serves handler as HTTP/2 with prior knowledge only, so
+// HTTP/1.1 requests get no usable answer
+func startH2CServer(t *testing.T, handler http.Handler) string {
+    t.Helper()
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { listener.Close() })
+
+    server := &http2.Server{}
+    base := &http.Server{ErrorLog: log.New(io.Discard, "", 0)} // HTTP/1.1 probes are expected to fail
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            go server.ServeConn(conn, &http2.ServeConnOpts{Handler: handler, BaseConfig: base})
+        }
+    }()
+    return "http://" + listener.Addr().String()
+}
+
+func TestApplyH2CMatchers(t *testing.T) {
+    target := startH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Server", "h2c-only")
+        fmt.Fprint(w, "maintenance page")
+    }))
+
+    tests := []struct {
+        name      string
+        configure func(*Config)
+        wantAlive bool
+    }{
+        {"no matchers", func(c *Config) {}, true},
+        {"status code matched", func(c *Config) { c.OnlyStatus = []int{200} }, true},
+        {"status code not matched", func(c *Config) { c.OnlyStatus = []int{404} }, false},
+        {"header filtered", func(c *Config) {
+            c.Filter.Headers = []HeaderMatcher{mustHeaderMatcher(t, "Server: h2c")}
+        }, false},
+        {"body filtered", func(c *Config) { c.Filter.Strings = []string{"maintenance"} }, false},
+        {"body matched", func(c *Config) { c.Match.Strings = []string{"maintenance"} }, true},
+    }
+    for _, tt := range tests {
+        config := defaultConfig()
+        config.H2C = true
+        tt.configure(config)
+        client := NewAliveHTTPClient(config)
+
+        result := client.CheckURL(context.Background(), target, config)
+        if result.Alive {
+            t.Fatalf("%s: HTTP/1.1 probe of an h2c-only server reported alive", tt.name)
+        }
+        client.applyH2C(context.Background(), result, config)
+        client.Close()
+
+        if result.H2C == nil || !result.H2C.PriorKnowledge {
+            t.Errorf("%s: prior knowledge h2c not detected", tt.name)
+        }
+        if result.Alive != tt.wantAlive {
+            t.Errorf("%s: alive = %t, want %t", tt.name, result.Alive, tt.wantAlive)
+        }
+        if tt.wantAlive && (result.Status != http.StatusOK || result.Server != "h2c-only") {
+            t.Errorf("%s: status %d, server %q; want the h2c response", tt.name, result.Status, result.Server)
+        }
+    }
+}
+
+func TestApplyH2CRespectsProbeStrategy(t *testing.T) {
+    target := startH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    hostPort := strings.TrimPrefix(target, "http://")
+
+    tests := []struct {
+        input    string
+        strategy string
+        want     bool // Whether a plaintext h2c request may be sent
+    }{
+        {hostPort, ProbeRespect, true},
+        {hostPort, ProbeHTTPS, false},
+        {"https://" + hostPort, ProbeRespect, false},
+        {"https://" + hostPort, ProbeBoth, true},
+        {"https://" + hostPort, ProbeHTTP, true},
+    }
+    for _, tt := range tests {
+        config := defaultConfig()
+        config.H2C = true
+        config.ProbeStrategy = tt.strategy
+        client := NewAliveHTTPClient(config)
+
+        // No status: CheckURL got no answer
+        result := &Result{URL: tt.input}
+        client.applyH2C(context.Background(), result, config)
+        client.Close()
+        if result.H2C.PriorKnowledge != tt.want {
+            t.Errorf("%s with -probe %s: prior knowledge = %t, want %t", tt.input, tt.strategy, result.H2C.PriorKnowledge, tt.want)
+        }
+    }
+}
+
+func mustHeaderMatcher(t *testing.T, expr string) HeaderMatcher {
+    t.Helper()
+    hm, err := ParseHeaderMatcher(expr)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return hm
+}
@@ -56,7 +56,7 @@ command_exists() {
 # Check Go version
 check_go_version() {
     local go_version=$(go version | awk '{print $3}' | sed 's/go//')
-    local required_version="1.19"
+    local required_version="1.24"
     
     if [ "$(printf '%s\n' "$required_version" "$go_version" | sort -V | head -n1)" != "$required_version" ]; then
         print_error "Go version $required_version or higher is required. Current version: $go_version"
@@ -138,7 +138,7 @@ print_status "Checking Go installation..."
 if ! command_exists go; then
     print_error "Go is not installed. Please install Go first."
     echo "Visit https://golang.org/doc/install for installation instructions"
-    echo "Minimum required version: 1.19"
+    echo "Minimum required version: 1.24"
     exit 1
 fi
 
@@ -152,17 +152,11 @@ print_status "Created installation directory: $INSTALL_DIR"
 
 # Copy source files with better detection
 print_status "Copying source files..."
-if [ -f "main.go" ]; then
-    cp main.go "$INSTALL_DIR/"
-    print_success "Source files copied (main.go)"
-elif [ -f "AliveHunter.go" ]; then
-    cp AliveHunter.go "$INSTALL_DIR/main.go"
-    print_success "Source files copied (AliveHunter.go → main.go)"
-elif [ -f "alivehunter.go" ]; then
-    cp alivehunter.go "$INSTALL_DIR/main.go"
-    print_success "Source files copied (alivehunter.go → main.go)"
+if ls *.go > /dev/null 2>&1; then
+    cp *.go "$INSTALL_DIR/"
+    print_success "Source files copied ($(ls *.go | wc -l | tr -d ' ') files)"
 else
-    print_error "Go source file not found. Expected: main.go, AliveHunter.go, or alivehunter.go"
+    print_error "Go source files not found. Run the installer from the AliveHunter directory"
     exit 1
 fi
 
@@ -185,6 +179,25 @@ go get golang.org/x/net/html
 print_info "Downloading golang.org/x/time/rate (rate limiting)..."
 go get golang.org/x/time/rate
 
+print_info "Downloading github.com/quic-go/quic-go (HTTP/3 probing)..."
+go get github.com/quic-go/quic-go
+
+print_info "Downloading modernc.org/sqlite (pure-Go SQLite result store)..."
+go get modernc.org/sqlite@v1.40.1
+
+print_info "Downloading github.com/robfig/cron/v3 (watch mode schedules)..."
+go get github.com/robfig/cron/v3
+
+print_info "Downloading gopkg.in/yaml.v3 and github.com/BurntSushi/toml (config files)..."
+go get gopkg.in/yaml.v3 github.com/BurntSushi/toml
+
+print_info "Downloading OpenTelemetry (probe tracing)..."
+go get go.opentelemetry.io/otel@v1.41.0 go.opentelemetry.io/otel/sdk@v1.41.0 \
+    go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp@v1.41.0 \
+    go.opentelemetry.io/otel/exporters/stdout/stdouttrace@v1.41.0 \
+    go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp@v0.66.0 \
+    go.opentelemetry.io/contrib/instrumentation/net/http/httptrace/otelhttptrace@v0.66.0
+
 print_status "Optimizing dependencies..."
 go mod tidy
 print_success "All dependencies installed successfully"
@@ -199,7 +212,7 @@ CGO_ENABLED=0 GOOS=$(go env GOOS) GOARCH=$(go env GOARCH) go build \
     -ldflags="-s -w -X main.VERSION=$VERSION" \
     -trimpath \
     -buildmode=exe \
-    main.go
+    .
 
 # Verify build
 if [ -f alivehunter ]; then
@@ -0,0 +1,120 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "strconv"
+    "strings"
+)
+
+// Target is a single input entry with optional pass-through metadata
+type Target struct {
+    URL  string
+    Meta map[string]interface{}
+}
+
+// InputOptions controls how -l/stdin input is parsed
+type InputOptions struct {
+    Format string   // lines, nmap, masscan, naabu or jsonl
+    Field  string   // Dot-path of the host/URL field in JSON lines
+    Meta   []string // Dot-paths of extra fields carried into Result
+}
+
+// newTargets wraps plain URLs as targets without metadata
+func newTargets(urls []string) []Target {
+    targets := make([]Target, 0, len(urls))
+    for _, url := range urls {
+        targets = append(targets, Target{URL: url})
+    }
+    return targets
+}
+
+// parseJSONLines reads JSON lines (subfinder -oJ, dnsx -json, amass -json) and
+// selects the target and metadata fields by dot-path
+func parseJSONLines(r io.Reader, opts InputOptions) ([]Target, error) {
+    field := opts.Field
+    if field == "" {
+        field = "host"
+    }
+
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
+
+    var targets []Target
+    lineCount, skipped, malformed := 0, 0, 0
+    var firstError string
+
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        lineCount++
+        if line == "" {
+            continue
+        }
+
+        var record interface{}
+        if err := json.Unmarshal([]byte(line), &record); err != nil {
+            // Exports cut off mid-write end in a partial line, keep the rest
+            if malformed == 0 {
+                firstError = fmt.Sprintf("line %d: %v", lineCount, err)
+            }
+            malformed++
+            continue
+        }
+
+        value, ok := lookupJSONPath(record, field)
+        host, isString := value.(string)
+        host = strings.TrimSpace(host)
+        if !ok || !isString || !isValidURL(host) {
+            skipped++
+            continue
+        }
+
+        target := Target{URL: host}
+        for _, path := range opts.Meta {
+            if metaValue, ok := lookupJSONPath(record, path); ok {
+                if target.Meta == nil {
+                    target.Meta = make(map[string]interface{})
+                }
+                target.Meta[path] = metaValue
+            }
+        }
+        targets = append(targets, target)
+    }
+
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    if malformed > 0 {
+        fmt.Fprintf(os.Stderr, "Skipped %d malformed JSON lines (first at %s)\n", malformed, firstError)
+    }
+    if skipped > 0 {
+        fmt.Fprintf(os.Stderr, "Skipped %d JSON lines without a valid %q field\n", skipped, field)
+    }
+    return targets, nil
+}
+
+// lookupJSONPath resolves a dot-path such as "addresses.0.ip" in decoded JSON
+func lookupJSONPath(node interface{}, path string) (interface{}, bool) {
+    for _, key := range strings.Split(path, ".") {
+        switch v := node.(type) {
+        case map[string]interface{}:
+            next, ok := v[key]
+            if !ok {
+                return nil, false
+            }
+            node = next
+        case []interface{}:
+            idx, err := strconv.Atoi(key)
+            if err != nil || idx < 0 || idx >= len(v) {
+                return nil, false
+            }
+            node = v[idx]
+        default:
+            return nil, false
+        }
+    }
+    return node, node != nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestParseJSONLines(t *testing.T) {
+    input := strings.Join([]string{
+        `{"host":"a.example.com","source":"crtsh","input":"example.com"}`,
+        ``,
+        `{"host":"b.example.com","a":["192.0.2.1","192.0.2.2"]}`,
+        `{"name":"no-host.example.com"}`,
+        `{"host":"bad host"}`,
+        `{"host":42}`,
+        `{"host":"c.example.com","source":`, // Truncated last write
+        `{"host":"d.example.com","resolver":{"ip":"192.0.2.53"}}`,
+    }, "\n")
+
+    targets, err := parseJSONLines(strings.NewReader(input), InputOptions{Meta: []string{"source", "a.1", "resolver.ip"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []Target{
+        {URL: "a.example.com", Meta: map[string]interface{}{"source": "crtsh"}},
+        {URL: "b.example.com", Meta: map[string]interface{}{"a.1": "192.0.2.2"}},
+        {URL: "d.example.com", Meta: map[string]interface{}{"resolver.ip": "192.0.2.53"}},
+    }
+    if !reflect.DeepEqual(targets, want) {
+        t.Errorf("targets = %+v, want %+v", targets, want)
+    }
+
+    // A nested host field
+    targets, err = parseJSONLines(strings.NewReader(`{"result":{"names":["x.example.com"]}}`), InputOptions{Field: "result.names.0"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(targets) != 1 || targets[0].URL != "x.example.com" || targets[0].Meta != nil {
+        t.Errorf("nested field: targets = %+v", targets)
+    }
+}
+
+func TestLookupJSONPath(t *testing.T) {
+    record := map[string]interface{}{
+        "host": "example.com",
+        "dns": map[string]interface{}{
+            "a":    []interface{}{"192.0.2.1", "192.0.2.2"},
+            "ttl":  float64(300),
+            "none": nil,
+        },
+    }
+    tests := []struct {
+        path   string
+        want   interface{}
+        wantOK bool
+    }{
+        {"host", "example.com", true},
+        {"dns.a.1", "192.0.2.2", true},
+        {"dns.ttl", float64(300), true},
+        {"dns.a", []interface{}{"192.0.2.1", "192.0.2.2"}, true},
+        {"dns.a.2", nil, false},  // Index out of range
+        {"dns.a.x", nil, false},  // Not an index
+        {"dns.none", nil, false}, // JSON null counts as missing
+        {"host.name", nil, false},
+        {"missing", nil, false},
+    }
+    for _, tt := range tests {
+        got, ok := lookupJSONPath(record, tt.path)
+        if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("lookupJSONPath(%q) = %v, %t; want %v, %t", tt.path, got, ok, tt.want, tt.wantOK)
+        }
+    }
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+    "bytes"
+    "flag"
+    "fmt"
+    "net/http"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+)
+
+const HEADERS_ALL = "all" // -headers value capturing every response header
+
+// Result error prefixes for responses the -m*/-f* options turned away,
+// followed by the kind that decided it, e.g. "filtered: length"
+const (
+    ErrorNotMatched = "not_matched"
+    ErrorFiltered   = "filtered"
+)
+
+// HeaderMatcher tests a response header: "Name" checks it exists,
+// "Name: regex" matches the regex against its value
+type HeaderMatcher struct {
+    Name    string
+    Pattern *regexp.Regexp // nil when only checking the header exists
+}
+
+// ParseHeaderMatcher parses a -match-header or -filter-header expression
+func ParseHeaderMatcher(expr string) (HeaderMatcher, error) {
+    name, pattern, hasPattern := strings.Cut(expr, ":")
+    name = strings.TrimSpace(name)
+    if name == "" {
+        return HeaderMatcher{}, fmt.Errorf("missing header name in %q", expr)
+    }
+
+    hm := HeaderMatcher{Name: http.CanonicalHeaderKey(name)}
+    if hasPattern {
+        re, err := regexp.Compile(strings.TrimSpace(pattern))
+        if err != nil {
+            return HeaderMatcher{}, fmt.Errorf("invalid regex in %q: %v", expr, err)
+        }
+        hm.Pattern = re
+    }
+    return hm, nil
+}
+
+// Match reports whether the header is present and, with a pattern, whether
+// any of its values matches
+func (hm HeaderMatcher) Match(header http.Header) bool {
+    values, ok := header[hm.Name]
+    if !ok {
+        return false
+    }
+    if hm.Pattern == nil {
+        return true
+    }
+    for _, value := range values {
+        if hm.Pattern.MatchString(value) {
+            return true
+        }
+    }
+    return false
+}
+
+// String returns the matcher as it was written
+func (hm HeaderMatcher) String() string {
+    if hm.Pattern == nil {
+        return hm.Name
+    }
+    return hm.Name + ": " + hm.Pattern.String()
+}
+
+// MarshalText keeps matchers readable in stored and distributed configs
+func (hm HeaderMatcher) MarshalText() ([]byte, error) {
+    return []byte(hm.String()), nil
+}
+
+// UnmarshalText parses a matcher expression
+func (hm *HeaderMatcher) UnmarshalText(text []byte) error {
+    parsed, err := ParseHeaderMatcher(string(text))
+    if err != nil {
+        return err
+    }
+    *hm = parsed
+    return nil
+}
+
+// headerMatcherFlag collects a repeatable matcher flag
+type headerMatcherFlag struct {
+    matchers *[]HeaderMatcher
+}
+
+func (f headerMatcherFlag) String() string {
+    if f.matchers == nil {
+        return ""
+    }
+    exprs := make([]string, len(*f.matchers))
+    for i, hm := range *f.matchers {
+        exprs[i] = hm.String()
+    }
+    return strings.Join(exprs, ", ")
+}
+
+// Repeatable marks the flag as taking one value per config file list item
+func (f headerMatcherFlag) Repeatable() {}
+
+func (f headerMatcherFlag) Set(expr string) error {
+    hm, err := ParseHeaderMatcher(expr)
+    if err != nil {
+        return err
+    }
+    *f.matchers = append(*f.matchers, hm)
+    return nil
+}
+
+// ResponseMatchers is one side of the -m*/-f* options. Values of one kind
+// are alternatives; as a match set every configured kind must match, as a
+// filter set any matching kind drops the response
+type ResponseMatchers struct {
+    Headers []HeaderMatcher `json:"headers,omitempty"` // -match-header / -filter-header
+    Regex   []Pattern       `json:"regex,omitempty"`   // -mr / -fr, on the body
+    Strings []string        `json:"strings,omitempty"` // -ms / -fs, on the body
+    Length  []IntRange      `json:"length,omitempty"`  // -ml / -fl
+    Words   []IntRange      `json:"words,omitempty"`   // -mwc / -fwc
+    Lines   []IntRange      `json:"lines,omitempty"`   // -mlc / -flc
+    Time    []TimeCondition `json:"time,omitempty"`    // -mrt / -frt
+}
+
+// needsBody reports whether the matchers look at the response body; length
+// needs it too, chunked responses carry no Content-Length
+func (rm *ResponseMatchers) needsBody() bool {
+    return len(rm.Regex) > 0 || len(rm.Strings) > 0 || len(rm.Length) > 0 || len(rm.Words) > 0 || len(rm.Lines) > 0
+}
+
+// configured reports whether any matcher kind is set
+func (rm *ResponseMatchers) configured() bool {
+    return len(rm.Headers) > 0 || len(rm.Regex) > 0 || len(rm.Strings) > 0 || len(rm.Length) > 0 ||
+        len(rm.Words) > 0 || len(rm.Lines) > 0 || len(rm.Time) > 0
+}
+
+// matcherCheck is the outcome of one configured kind
+type matcherCheck struct {
+    kind    string
+    matched bool
+}
+
+// checks returns one outcome per configured kind
+func (rm *ResponseMatchers) checks(header http.Header, body []byte, result *Result) []matcherCheck {
+    var outcomes []matcherCheck
+    if len(rm.Headers) > 0 {
+        matched := false
+        for _, hm := range rm.Headers {
+            matched = matched || hm.Match(header)
+        }
+        outcomes = append(outcomes, matcherCheck{"header", matched})
+    }
+    if len(rm.Regex) > 0 {
+        matched := false
+        for _, p := range rm.Regex {
+            matched = matched || p.Match(body)
+        }
+        outcomes = append(outcomes, matcherCheck{"regex", matched})
+    }
+    if len(rm.Strings) > 0 {
+        matched := false
+        for _, str := range rm.Strings {
+            matched = matched || bytes.Contains(body, []byte(str))
+        }
+        outcomes = append(outcomes, matcherCheck{"string", matched})
+    }
+    if len(rm.Length) > 0 {
+        outcomes = append(outcomes, matcherCheck{"length", inRanges(rm.Length, int(result.Length))})
+    }
+    if len(rm.Words) > 0 {
+        outcomes = append(outcomes, matcherCheck{"words", inRanges(rm.Words, result.Words)})
+    }
+    if len(rm.Lines) > 0 {
+        outcomes = append(outcomes, matcherCheck{"lines", inRanges(rm.Lines, result.Lines)})
+    }
+    if len(rm.Time) > 0 {
+        matched := false
+        for _, tc := range rm.Time {
+            matched = matched || tc.Match(result.ResponseTime)
+        }
+        outcomes = append(outcomes, matcherCheck{"time", matched})
+    }
+    return outcomes
+}
+
+// responseAllowed applies the match set (every kind must match) and the
+// filter set (no kind may match); a turned away result gets the reason as
+// its error so it is not mistaken for a failed request
+func responseAllowed(header http.Header, body []byte, result *Result, config *Config) bool {
+    if !config.Match.configured() && !config.Filter.configured() {
+        return true
+    }
+    for _, check := range config.Filter.checks(header, body, result) {
+        if check.matched {
+            result.Error = ErrorFiltered + ": " + check.kind
+            return false
+        }
+    }
+    for _, check := range config.Match.checks(header, body, result) {
+        if !check.matched {
+            result.Error = ErrorNotMatched + ": " + check.kind
+            return false
+        }
+    }
+    return true
+}
+
+// countBody sets the word and line counts of a read body; they cover only
+// the bytes read, not the rest of a longer response
+func countBody(result *Result, body []byte) {
+    if len(body) == 0 {
+        return
+    }
+    result.Words = len(strings.Fields(string(body)))
+    result.Lines = bytes.Count(body, []byte("\n"))
+    if body[len(body)-1] != '\n' {
+        result.Lines++
+    }
+}
+
+// Pattern is a regex that round-trips through JSON as its source text
+type Pattern struct {
+    *regexp.Regexp
+}
+
+// MarshalText returns the regex source
+func (p Pattern) MarshalText() ([]byte, error) {
+    if p.Regexp == nil {
+        return nil, nil
+    }
+    return []byte(p.String()), nil
+}
+
+// UnmarshalText compiles the regex
+func (p *Pattern) UnmarshalText(text []byte) error {
+    re, err := regexp.Compile(string(text))
+    if err != nil {
+        return fmt.Errorf("invalid regex %q: %v", text, err)
+    }
+    p.Regexp = re
+    return nil
+}
+
+// IntRange is an inclusive range written as "100" or "100-200"
+type IntRange struct {
+    Min, Max int
+}
+
+// String returns the range as it is written on the command line
+func (r IntRange) String() string {
+    if r.Min == r.Max {
+        return strconv.Itoa(r.Min)
+    }
+    return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// MarshalText returns the range text
+func (r IntRange) MarshalText() ([]byte, error) {
+    return []byte(r.String()), nil
+}
+
+// UnmarshalText parses "n" or "min-max"
+func (r *IntRange) UnmarshalText(text []byte) error {
+    low, high, isRange := strings.Cut(strings.TrimSpace(string(text)), "-")
+    min, err := strconv.Atoi(strings.TrimSpace(low))
+    if err != nil {
+        return fmt.Errorf("invalid number or range %q", text)
+    }
+    max := min
+    if isRange {
+        if max, err = strconv.Atoi(strings.TrimSpace(high)); err != nil || max < min {
+            return fmt.Errorf("invalid range %q", text)
+        }
+    }
+    r.Min, r.Max = min, max
+    return nil
+}
+
+// inRanges reports whether n falls in any of the ranges
+func inRanges(ranges []IntRange, n int) bool {
+    for _, r := range ranges {
+        if n >= r.Min && n <= r.Max {
+            return true
+        }
+    }
+    return false
+}
+
+// TimeCondition compares the response time, e.g. "<500ms" or ">=2s"
+type TimeCondition struct {
+    Op    string
+    Limit time.Duration
+}
+
+// Match reports whether the response time satisfies the condition
+func (tc TimeCondition) Match(d time.Duration) bool {
+    switch tc.Op {
+    case "<":
+        return d < tc.Limit
+    case "<=":
+        return d <= tc.Limit
+    case ">":
+        return d > tc.Limit
+    default:
+        return d >= tc.Limit
+    }
+}
+
+// MarshalText returns the condition as written
+func (tc TimeCondition) MarshalText() ([]byte, error) {
+    return []byte(tc.Op + tc.Limit.String()), nil
+}
+
+// UnmarshalText parses an operator followed by a Go duration
+func (tc *TimeCondition) UnmarshalText(text []byte) error {
+    expr := strings.TrimSpace(string(text))
+    for _, op := range []string{"<=", ">=", "<", ">"} {
+        if strings.HasPrefix(expr, op) {
+            limit, err := time.ParseDuration(strings.TrimSpace(expr[len(op):]))
+            if err != nil {
+                return fmt.Errorf("invalid duration in %q", expr)
+            }
+            tc.Op, tc.Limit = op, limit
+            return nil
+        }
+    }
+    return fmt.Errorf("invalid response time condition %q (use e.g. <500ms or >2s)", expr)
+}
+
+// rangeFlag is a comma separated list of ranges; each Set appends
+type rangeFlag struct {
+    ranges *[]IntRange
+}
+
+func (f rangeFlag) String() string {
+    if f.ranges == nil {
+        return ""
+    }
+    items := make([]string, len(*f.ranges))
+    for i, r := range *f.ranges {
+        items[i] = r.String()
+    }
+    return strings.Join(items, ",")
+}
+
+func (f rangeFlag) Set(text string) error {
+    for _, item := range strings.Split(text, ",") {
+        if item = strings.TrimSpace(item); item == "" {
+            continue
+        }
+        var r IntRange
+        if err := r.UnmarshalText([]byte(item)); err != nil {
+            return err
+        }
+        *f.ranges = append(*f.ranges, r)
+    }
+    return nil
+}
+
+// timeConditionFlag is a comma separated list of response time conditions
+type timeConditionFlag struct {
+    conditions *[]TimeCondition
+}
+
+func (f timeConditionFlag) String() string {
+    if f.conditions == nil {
+        return ""
+    }
+    items := make([]string, len(*f.conditions))
+    for i, tc := range *f.conditions {
+        items[i] = tc.Op + tc.Limit.String()
+    }
+    return strings.Join(items, ",")
+}
+
+func (f timeConditionFlag) Set(text string) error {
+    for _, item := range strings.Split(text, ",") {
+        if item = strings.TrimSpace(item); item == "" {
+            continue
+        }
+        var tc TimeCondition
+        if err := tc.UnmarshalText([]byte(item)); err != nil {
+            return err
+        }
+        *f.conditions = append(*f.conditions, tc)
+    }
+    return nil
+}
+
+// patternFlag collects a repeatable regex flag; regexes may contain commas
+type patternFlag struct {
+    patterns *[]Pattern
+}
+
+func (f patternFlag) String() string {
+    if f.patterns == nil {
+        return ""
+    }
+    items := make([]string, len(*f.patterns))
+    for i, p := range *f.patterns {
+        items[i] = p.String()
+    }
+    return strings.Join(items, ", ")
+}
+
+// Repeatable marks the flag as taking one value per config file list item
+func (f patternFlag) Repeatable() {}
+
+func (f patternFlag) Set(text string) error {
+    var p Pattern
+    if err := p.UnmarshalText([]byte(text)); err != nil {
+        return err
+    }
+    *f.patterns = append(*f.patterns, p)
+    return nil
+}
+
+// stringFlag collects a repeatable string flag
+type stringFlag struct {
+    values *[]string
+}
+
+func (f stringFlag) String() string {
+    if f.values == nil {
+        return ""
+    }
+    return strings.Join(*f.values, ", ")
+}
+
+// Repeatable marks the flag as taking one value per config file list item
+func (f stringFlag) Repeatable() {}
+
+func (f stringFlag) Set(text string) error {
+    *f.values = append(*f.values, text)
+    return nil
+}
+
+// matcherFlags registers the -m*/-f* flags for one side
+func matcherFlags(fs *flag.FlagSet, rm *ResponseMatchers, prefix, verb string) {
+    fs.Var(patternFlag{&rm.Regex}, prefix+"r", verb+" responses whose body matches this regex (repeatable)")
+    fs.Var(stringFlag{&rm.Strings}, prefix+"s", verb+" responses whose body contains this string (repeatable)")
+    fs.Var(rangeFlag{&rm.Length}, prefix+"l", verb+" responses by content length, e.g. 0,100-200")
+    fs.Var(rangeFlag{&rm.Words}, prefix+"wc", verb+" responses by body word count, e.g. 10-50")
+    fs.Var(rangeFlag{&rm.Lines}, prefix+"lc", verb+" responses by body line count, e.g. 1,20-30")
+    fs.Var(timeConditionFlag{&rm.Time}, prefix+"rt", verb+" responses by response time, e.g. '<500ms' or '>2s'")
+}
+
+// captureHeaders returns the -headers selection, multiple values joined
+func captureHeaders(header http.Header, names []string) map[string]string {
+    if len(names) == 0 {
+        return nil
+    }
+    captured := make(map[string]string)
+    if len(names) == 1 && strings.EqualFold(names[0], HEADERS_ALL) {
+        for name, values := range header {
+            captured[name] = strings.Join(values, ", ")
+        }
+    } else {
+        for _, name := range names {
+            if values := header.Values(name); len(values) > 0 {
+                captured[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+            }
+        }
+    }
+    if len(captured) == 0 {
+        return nil
+    }
+    return captured
+}
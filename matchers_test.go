@@ -0,0 +1,75 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "strings"
+    "testing"
+)
+
+func TestResponseAllowed(t *testing.T) {
+    header := http.Header{"Server": {"nginx"}}
+    body := []byte("<html>Index of /\nadmin</html>\n")
+    result := &Result{Length: int64(len(body)), Words: 3, Lines: 2}
+
+    tests := []struct {
+        name    string
+        match   ResponseMatchers
+        filter  ResponseMatchers
+        want    bool
+        wantErr string
+    }{
+        {"no matchers", ResponseMatchers{}, ResponseMatchers{}, true, ""},
+        {"string matches", ResponseMatchers{Strings: []string{"nothing", "Index of"}}, ResponseMatchers{}, true, ""},
+        {"every kind must match", ResponseMatchers{Strings: []string{"Index of"}, Words: []IntRange{{10, 20}}}, ResponseMatchers{}, false, "not_matched: words"},
+        {"regex does not match", ResponseMatchers{Regex: []Pattern{{regexp.MustCompile(`(?i)login`)}}}, ResponseMatchers{}, false, "not_matched: regex"},
+        {"filtered by length", ResponseMatchers{}, ResponseMatchers{Length: []IntRange{{int(result.Length), int(result.Length)}}}, false, "filtered: length"},
+        {"filter kind not matching", ResponseMatchers{}, ResponseMatchers{Headers: []HeaderMatcher{mustHeaderMatcher(t, "Server: apache")}}, true, ""},
+        {"filter wins over match", ResponseMatchers{Lines: []IntRange{{2, 2}}}, ResponseMatchers{Strings: []string{"admin"}}, false, "filtered: string"},
+    }
+    for _, tt := range tests {
+        config := defaultConfig()
+        config.Match = tt.match
+        config.Filter = tt.filter
+        checked := *result
+        if got := responseAllowed(header, body, &checked, config); got != tt.want || checked.Error != tt.wantErr {
+            t.Errorf("%s: allowed = %t, error %q; want %t, %q", tt.name, got, checked.Error, tt.want, tt.wantErr)
+        }
+    }
+}
+
+func TestProbeBodyMatchers(t *testing.T) {
+    // Chunked and well past MaxBodySize, so neither Content-Length nor a
+    // body cut at MaxBodySize gives the real size
+    body := strings.Repeat("word word\n", 5000)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        fmt.Fprint(w, body)
+    }))
+    defer server.Close()
+
+    tests := []struct {
+        name  string
+        match ResponseMatchers
+    }{
+        {"string", ResponseMatchers{Strings: []string{"word"}}},
+        {"length", ResponseMatchers{Length: []IntRange{{len(body), len(body)}}}},
+    }
+    for _, tt := range tests {
+        config := defaultConfig()
+        config.FastMode = true
+        config.Match = tt.match
+        client := NewAliveHTTPClient(config)
+        result, err := client.probe(context.Background(), server.URL, config)
+        client.Close()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !result.Alive || result.Length != int64(len(body)) || result.Words != 10000 || result.Lines != 5000 {
+            t.Errorf("%s: alive %t, length %d, %d words, %d lines; want alive, %d, 10000, 5000",
+                tt.name, result.Alive, result.Length, result.Words, result.Lines, len(body))
+        }
+    }
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+const METRICS_RATE_WINDOW = 10 // Seconds of history behind the effective rate gauge
+
+// Response time histogram buckets in seconds
+var metricsTimeBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics accumulates scan counters across scans for Prometheus scraping
+type Metrics struct {
+    mu          sync.Mutex
+    checked     uint64
+    alive       uint64
+    verified    uint64
+    errors      uint64
+    errorKinds  map[string]uint64
+    statusCodes map[int]uint64
+    timeBuckets []uint64 // Cumulative counts per metricsTimeBuckets entry
+    timeSum     float64
+    timeCount   uint64
+    scans       uint64 // Completed scans
+    running     bool
+    targets     int64   // Targets in the current or last scan
+    rateLimit   float64 // Configured requests per second, 0 when unlimited
+    samples     [METRICS_RATE_WINDOW + 1]uint64 // checked, sampled once a second
+    sampled     int
+}
+
+// NewMetrics creates an empty metrics set
+func NewMetrics(config *Config) *Metrics {
+    m := &Metrics{
+        errorKinds:  make(map[string]uint64),
+        statusCodes: make(map[int]uint64),
+        timeBuckets: make([]uint64, len(metricsTimeBuckets)),
+    }
+    if !config.FastMode {
+        m.rateLimit = config.Rate
+    }
+    return m
+}
+
+// ServeMetrics listens on addr and serves /metrics in the background
+func ServeMetrics(addr string, m *Metrics) error {
+    listener, err := net.Listen("tcp", addr)
+    if err != nil {
+        return fmt.Errorf("error starting metrics endpoint: %v", err)
+    }
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+        m.WriteTo(w)
+    })
+    server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+    go server.Serve(listener)
+    go m.sampleRate()
+    return nil
+}
+
+// sampleRate records the checked counter every second for the rate gauge
+func (m *Metrics) sampleRate() {
+    ticker := time.NewTicker(1 * time.Second)
+    defer ticker.Stop()
+    for range ticker.C {
+        m.mu.Lock()
+        copy(m.samples[1:], m.samples[:len(m.samples)-1])
+        m.samples[0] = m.checked
+        if m.sampled < len(m.samples) {
+            m.sampled++
+        }
+        m.mu.Unlock()
+    }
+}
+
+// ScanStarted marks a scan of n targets as running
+func (m *Metrics) ScanStarted(n int64) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.running = true
+    m.targets = n
+}
+
+// ScanFinished marks the current scan as done
+func (m *Metrics) ScanFinished() {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.running = false
+    m.scans++
+}
+
+// Observe counts one result, the same way runScan updates Stats
+func (m *Metrics) Observe(result *Result) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    m.checked++
+    if result.Alive {
+        m.alive++
+    }
+    if result.Verified {
+        m.verified++
+    }
+    if result.Error != "" {
+        m.errors++
+        m.errorKinds[errorKind(result.Error)]++
+    }
+    if result.Status > 0 {
+        m.statusCodes[result.Status]++
+
+        seconds := result.ResponseTime.Seconds()
+        for i, bound := range metricsTimeBuckets {
+            if seconds <= bound {
+                m.timeBuckets[i]++
+            }
+        }
+        m.timeSum += seconds
+        m.timeCount++
+    }
+}
+
+// errorKind maps a result error to a small set of label values
+func errorKind(err string) string {
+    lower := strings.ToLower(err)
+    switch {
+    case strings.HasPrefix(lower, "invalid_url"):
+        return "invalid_url"
+    case strings.HasPrefix(lower, "no_response"):
+        return "no_response"
+    case strings.HasPrefix(lower, "false_positive"):
+        return "false_positive"
+    case strings.HasPrefix(lower, "verification_failed"):
+        return "verification_failed"
+    case strings.HasPrefix(lower, ErrorNotMatched):
+        return ErrorNotMatched
+    case strings.HasPrefix(lower, ErrorFiltered):
+        return ErrorFiltered
+    case strings.Contains(lower, "timeout") || strings.Contains(lower, "deadline exceeded"):
+        return "timeout"
+    case strings.Contains(lower, "no such host") || strings.Contains(lower, "server misbehaving"):
+        return "dns"
+    case strings.Contains(lower, "connection refused"):
+        return "refused"
+    case strings.Contains(lower, "connection reset") || strings.Contains(lower, "broken pipe") || strings.Contains(lower, "eof"):
+        return "reset"
+    case strings.Contains(lower, "tls") || strings.Contains(lower, "x509") || strings.Contains(lower, "certificate"):
+        return "tls"
+    default:
+        return "other"
+    }
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    var b strings.Builder
+    counter := func(name, help string, value uint64) {
+        fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+    }
+    gauge := func(name, help string, value float64) {
+        fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+    }
+
+    counter("alivehunter_checked_total", "Results checked.", m.checked)
+    counter("alivehunter_alive_total", "Results found alive.", m.alive)
+    counter("alivehunter_verified_total", "Results verified in verify mode.", m.verified)
+    counter("alivehunter_errors_total", "Results with an error.", m.errors)
+    counter("alivehunter_scans_total", "Scans completed.", m.scans)
+
+    b.WriteString("# HELP alivehunter_errors_by_kind_total Results with an error, by kind.\n")
+    b.WriteString("# TYPE alivehunter_errors_by_kind_total counter\n")
+    kinds := make([]string, 0, len(m.errorKinds))
+    for kind := range m.errorKinds {
+        kinds = append(kinds, kind)
+    }
+    sort.Strings(kinds)
+    for _, kind := range kinds {
+        fmt.Fprintf(&b, "alivehunter_errors_by_kind_total{kind=%q} %d\n", kind, m.errorKinds[kind])
+    }
+
+    b.WriteString("# HELP alivehunter_responses_total HTTP responses, by status code.\n")
+    b.WriteString("# TYPE alivehunter_responses_total counter\n")
+    codes := make([]int, 0, len(m.statusCodes))
+    for code := range m.statusCodes {
+        codes = append(codes, code)
+    }
+    sort.Ints(codes)
+    for _, code := range codes {
+        fmt.Fprintf(&b, "alivehunter_responses_total{code=\"%d\"} %d\n", code, m.statusCodes[code])
+    }
+
+    b.WriteString("# HELP alivehunter_response_time_seconds Response time of HTTP responses.\n")
+    b.WriteString("# TYPE alivehunter_response_time_seconds histogram\n")
+    for i, bound := range metricsTimeBuckets {
+        fmt.Fprintf(&b, "alivehunter_response_time_seconds_bucket{le=\"%g\"} %d\n", bound, m.timeBuckets[i])
+    }
+    fmt.Fprintf(&b, "alivehunter_response_time_seconds_bucket{le=\"+Inf\"} %d\n", m.timeCount)
+    fmt.Fprintf(&b, "alivehunter_response_time_seconds_sum %g\n", m.timeSum)
+    fmt.Fprintf(&b, "alivehunter_response_time_seconds_count %d\n", m.timeCount)
+
+    // Effective rate over the sampled window, not the configured limit
+    var rate float64
+    if m.sampled > 1 {
+        oldest := m.sampled - 1
+        rate = float64(m.samples[0]-m.samples[oldest]) / float64(oldest)
+    }
+    running := 0.0
+    if m.running {
+        running = 1
+    }
+    gauge("alivehunter_rate", "Effective results per second over the last 10 seconds.", rate)
+    gauge("alivehunter_rate_limit", "Configured requests per second, 0 when unlimited.", m.rateLimit)
+    gauge("alivehunter_scan_running", "1 while a scan is running.", running)
+    gauge("alivehunter_targets", "Targets in the current or last scan.", float64(m.targets))
+
+    n, err := io.WriteString(w, b.String())
+    return int64(n), err
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+)
+
+// metricValues parses exposition text into sample name (with labels) and value
+func metricValues(t *testing.T, text string) map[string]float64 {
+    t.Helper()
+    values := map[string]float64{}
+    for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+        if strings.HasPrefix(line, "#") {
+            continue
+        }
+        idx := strings.LastIndex(line, " ")
+        value, err := strconv.ParseFloat(line[idx+1:], 64)
+        if err != nil {
+            t.Fatalf("invalid sample %q", line)
+        }
+        values[line[:idx]] = value
+    }
+    return values
+}
+
+func TestMetricsExposition(t *testing.T) {
+    m := NewMetrics(defaultConfig())
+    m.ScanStarted(6)
+    for _, result := range []*Result{
+        {Status: 200, Alive: true, ResponseTime: 30 * time.Millisecond},
+        {Status: 200, Alive: true, Verified: true, ResponseTime: 300 * time.Millisecond},
+        {Status: 404, ResponseTime: 2 * time.Second, Error: ErrorNotMatched + ": string"},
+        {Status: 503, ResponseTime: 20 * time.Second}, // Past the last bucket
+        {Error: "connection_failed: dial tcp: i/o timeout"},
+        {Error: "connection_failed: dial tcp: lookup x: no such host"},
+    } {
+        m.Observe(result)
+    }
+    m.ScanFinished()
+
+    var b strings.Builder
+    if _, err := m.WriteTo(&b); err != nil {
+        t.Fatal(err)
+    }
+    values := metricValues(t, b.String())
+
+    want := map[string]float64{
+        "alivehunter_checked_total":                            6,
+        "alivehunter_alive_total":                              2,
+        "alivehunter_verified_total":                           1,
+        "alivehunter_errors_total":                             3,
+        "alivehunter_scans_total":                              1,
+        `alivehunter_errors_by_kind_total{kind="not_matched"}`: 1,
+        `alivehunter_errors_by_kind_total{kind="timeout"}`:     1,
+        `alivehunter_errors_by_kind_total{kind="dns"}`:         1,
+        `alivehunter_responses_total{code="200"}`:              2,
+        `alivehunter_responses_total{code="503"}`:              1,
+        `alivehunter_response_time_seconds_bucket{le="0.05"}`:  1,
+        `alivehunter_response_time_seconds_bucket{le="0.25"}`:  1,
+        `alivehunter_response_time_seconds_bucket{le="0.5"}`:   2,
+        `alivehunter_response_time_seconds_bucket{le="2.5"}`:   3,
+        `alivehunter_response_time_seconds_bucket{le="10"}`:    3,
+        `alivehunter_response_time_seconds_bucket{le="+Inf"}`:  4,
+        "alivehunter_response_time_seconds_count":              4,
+        "alivehunter_scan_running":                             0,
+        "alivehunter_targets":                                  6,
+        "alivehunter_rate_limit":                               DEFAULT_RATE,
+    }
+    for name, value := range want {
+        if got, ok := values[name]; !ok || got != value {
+            t.Errorf("%s = %v (present %t), want %v", name, got, ok, value)
+        }
+    }
+    if sum := values["alivehunter_response_time_seconds_sum"]; sum < 22.32 || sum > 22.34 {
+        t.Errorf("response time sum = %v, want 22.33", sum)
+    }
+
+    // Buckets are cumulative and the +Inf bucket matches the count
+    previous := 0.0
+    for _, bound := range metricsTimeBuckets {
+        name := `alivehunter_response_time_seconds_bucket{le="` + strconv.FormatFloat(bound, 'g', -1, 64) + `"}`
+        if values[name] < previous {
+            t.Errorf("%s = %v, below the previous bucket's %v", name, values[name], previous)
+        }
+        previous = values[name]
+    }
+    if inf := values[`alivehunter_response_time_seconds_bucket{le="+Inf"}`]; inf < previous || inf != values["alivehunter_response_time_seconds_count"] {
+        t.Errorf("+Inf bucket %v, last bucket %v, count %v", inf, previous, values["alivehunter_response_time_seconds_count"])
+    }
+}
+
+func TestErrorKind(t *testing.T) {
+    tests := []struct {
+        err  string
+        want string
+    }{
+        {"invalid_url", "invalid_url"},
+        {"no_response", "no_response"},
+        {"false_positive_detected", "false_positive"},
+        {"verification_failed: verification_request_failed: EOF", "verification_failed"},
+        {"filtered: length", "filtered"},
+        {"not_matched: regex", "not_matched"},
+        {"connection_failed: context deadline exceeded", "timeout"},
+        {"connection_failed: dial tcp: connect: connection refused", "refused"},
+        {"connection_failed: read: connection reset by peer", "reset"},
+        {"connection_failed: tls: failed to verify certificate: x509: unknown authority", "tls"},
+        {"something else", "other"},
+    }
+    for _, tt := range tests {
+        if got := errorKind(tt.err); got != tt.want {
+            t.Errorf("errorKind(%q) = %q, want %q", tt.err, got, tt.want)
+        }
+    }
+}
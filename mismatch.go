@@ -0,0 +1,124 @@
+package main
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "strings"
+)
+
+// Scheme mismatches reported in Result.SchemeMismatch
+const (
+    MismatchHTTPSOnPlain = "https_on_plaintext_port" // TLS handshake sent to a plaintext HTTP port
+    MismatchHTTPOnTLS    = "http_on_tls_port"        // Plaintext HTTP sent to a TLS port
+)
+
+// Error fragments produced when speaking TLS to a plaintext HTTP server
+var httpsOnPlainErrors = []string{
+    "server gave HTTP response to HTTPS client",
+    "first record does not look like a TLS handshake",
+}
+
+// Response bodies servers send when plaintext HTTP reaches a TLS port
+var httpOnTLSBodies = []string{
+    "plain http request was sent to https port",         // nginx, OpenResty
+    "client sent an http request to an https server",    // Go net/http
+    "speaking plain http to an ssl-enabled server port", // Apache
+    "this combination of host and port requires tls",    // Caddy
+}
+
+// Server headers of front-ends that answer plaintext on a TLS port with a 400
+// page; Go servers such as Caddy send that 400 without any Server header
+var httpOnTLSServers = []string{"nginx", "openresty", "tengine", "apache", "caddy"}
+
+// probeSchemeAware probes fullURL and, when the scheme does not match the
+// port, re-probes with the other scheme and records the mismatch. A scheme
+// forced with -probe http or -probe https is never switched
+func (ac *AliveHTTPClient) probeSchemeAware(ctx context.Context, fullURL string, config *Config) (*Result, error) {
+    probed, err := ac.probe(ctx, fullURL, config)
+    if config.ProbeStrategy == ProbeHTTP || config.ProbeStrategy == ProbeHTTPS {
+        return probed, err
+    }
+
+    mismatch := ""
+    switch {
+    case err != nil && strings.HasPrefix(fullURL, "https://") && isHTTPSOnPlainError(err):
+        mismatch = MismatchHTTPSOnPlain
+    case err != nil && strings.HasPrefix(fullURL, "http://") && isHTTPOnTLSError(err):
+        mismatch = MismatchHTTPOnTLS
+    case err == nil && strings.HasPrefix(fullURL, "http://") && mayBeHTTPOnTLS(probed) && ac.isHTTPOnTLSResponse(ctx, fullURL):
+        mismatch = MismatchHTTPOnTLS
+    }
+    if mismatch == "" {
+        return probed, err
+    }
+
+    fixed, fixErr := ac.probe(ctx, switchScheme(fullURL), config)
+    if fixErr != nil {
+        return probed, err
+    }
+    fixed.SchemeMismatch = mismatch
+    return fixed, nil
+}
+
+// isHTTPSOnPlainError reports whether a TLS failure came from a plaintext server
+func isHTTPSOnPlainError(err error) bool {
+    for _, fragment := range httpsOnPlainErrors {
+        if strings.Contains(err.Error(), fragment) {
+            return true
+        }
+    }
+    return false
+}
+
+// isHTTPOnTLSError reports whether a plaintext request got a TLS record back;
+// TLS alerts start with \x15\x03 and show up as a malformed HTTP response
+func isHTTPOnTLSError(err error) bool {
+    msg := err.Error()
+    return strings.Contains(msg, "malformed HTTP response") && strings.Contains(msg, `\x15\x03`)
+}
+
+// mayBeHTTPOnTLS reports whether a response is a 400 from a server known to
+// send one for plaintext on a TLS port, so other 400s cost no extra request
+func mayBeHTTPOnTLS(result *Result) bool {
+    if result.Status != http.StatusBadRequest {
+        return false
+    }
+    if result.Server == "" {
+        return true
+    }
+    server := strings.ToLower(result.Server)
+    for _, name := range httpOnTLSServers {
+        if strings.Contains(server, name) {
+            return true
+        }
+    }
+    return false
+}
+
+// isHTTPOnTLSResponse fetches the 400 body and checks for the messages servers
+// return when plaintext HTTP is sent to a TLS port (HEAD responses have no body)
+func (ac *AliveHTTPClient) isHTTPOnTLSResponse(ctx context.Context, fullURL string) bool {
+    resp, err := ac.fetchBody(ctx, fullURL, RequestTypeCheck)
+    if err != nil {
+        return false
+    }
+    defer resp.Body.Close()
+
+    body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+    content := strings.ToLower(string(body))
+    for _, signature := range httpOnTLSBodies {
+        if strings.Contains(content, signature) {
+            return true
+        }
+    }
+    return false
+}
+
+// switchScheme swaps http:// and https://
+func switchScheme(fullURL string) string {
+    if strings.HasPrefix(fullURL, "https://") {
+        return "http://" + strings.TrimPrefix(fullURL, "https://")
+    }
+    return "https://" + strings.TrimPrefix(fullURL, "http://")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+    "context"
+    "io"
+    "log"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync/atomic"
+    "testing"
+)
+
+func TestProbeSchemeAwareHTTPOnTLS(t *testing.T) {
+    // Go's TLS server answers plaintext with a bare 400 and no Server header
+    server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    server.Config.ErrorLog = log.New(io.Discard, "", 0)
+    server.StartTLS()
+    defer server.Close()
+    plainURL := "http://" + strings.TrimPrefix(server.URL, "https://")
+
+    config := defaultConfig()
+    client := NewAliveHTTPClient(config)
+    defer client.Close()
+
+    result, err := client.probeSchemeAware(context.Background(), plainURL, config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if result.SchemeMismatch != MismatchHTTPOnTLS || result.URL != server.URL || !result.Alive {
+        t.Errorf("got %s (mismatch %q, alive %t); want %s switched to HTTPS", result.URL, result.SchemeMismatch, result.Alive, server.URL)
+    }
+
+    // A forced scheme is kept
+    config.ProbeStrategy = ProbeHTTP
+    result, err = client.probeSchemeAware(context.Background(), plainURL, config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if result.SchemeMismatch != "" || result.URL != plainURL {
+        t.Errorf("-probe http: got %s (mismatch %q), want %s unchanged", result.URL, result.SchemeMismatch, plainURL)
+    }
+}
+
+func TestProbeSchemeAwareOrdinary400(t *testing.T) {
+    var requests int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&requests, 1)
+        w.Header().Set("Server", "gunicorn")
+        http.Error(w, "missing parameter", http.StatusBadRequest)
+    }))
+    defer server.Close()
+
+    config := defaultConfig()
+    client := NewAliveHTTPClient(config)
+    defer client.Close()
+
+    result, err := client.probeSchemeAware(context.Background(), server.URL, config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if result.SchemeMismatch != "" {
+        t.Errorf("mismatch = %q, want none", result.SchemeMismatch)
+    }
+    if n := atomic.LoadInt32(&requests); n != 1 {
+        t.Errorf("%d requests for an application 400, want 1", n)
+    }
+}
+
+func TestMayBeHTTPOnTLS(t *testing.T) {
+    tests := []struct {
+        status int
+        server string
+        want   bool
+    }{
+        {400, "", true},
+        {400, "nginx/1.25.3", true},
+        {400, "Apache/2.4.58 (Debian)", true},
+        {400, "openresty", true},
+        {400, "gunicorn", false},
+        {400, "Microsoft-IIS/10.0", false},
+        {200, "nginx", false},
+    }
+    for _, tt := range tests {
+        if got := mayBeHTTPOnTLS(&Result{Status: tt.status, Server: tt.server}); got != tt.want {
+            t.Errorf("mayBeHTTPOnTLS(%d, %q) = %t, want %t", tt.status, tt.server, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+    "hash/fnv"
+    "net"
+    "net/url"
+    "path"
+    "strings"
+
+    "golang.org/x/net/idna"
+)
+
+const (
+    DEDUP_EXACT_LIMIT = 1000000 // Exact dedup entries kept before switching to a Bloom filter
+    BLOOM_BITS        = 1 << 27 // 16MB Bloom filter, ~0.2% false positives at 10M targets
+    BLOOM_HASHES      = 7
+)
+
+// idnaProfile converts IDNs to punycode without rejecting underscores in labels
+var idnaProfile = idna.New(idna.MapForLookup(), idna.Transitional(false), idna.StrictDomainName(false))
+
+// NormalizeStats reports what input normalisation collapsed or dropped
+type NormalizeStats struct {
+    Input      int
+    Duplicates int
+    Invalid    int
+    Bloom      bool // Whether the Bloom filter was used for deduplication
+}
+
+// normalizeTargets canonicalises every target and drops duplicates, keeping
+// the first occurrence (and its metadata). It compacts the slice in place, so
+// no second copy of the input is made
+func normalizeTargets(targets []Target) ([]Target, NormalizeStats) {
+    stats := NormalizeStats{Input: len(targets)}
+    dedup := newDeduper()
+    unique := targets[:0]
+
+    for _, target := range targets {
+        normalized, ok := normalizeTarget(target.URL)
+        if !ok {
+            stats.Invalid++
+            continue
+        }
+        if dedup.Seen(normalized) {
+            stats.Duplicates++
+            continue
+        }
+        target.URL = normalized
+        unique = append(unique, target)
+    }
+
+    stats.Bloom = dedup.bloom != nil
+    return unique, stats
+}
+
+// normalizeTarget lowercases the host, converts IDNs to punycode, strips
+// wildcard labels, trailing dots and default ports, and cleans the path
+func normalizeTarget(raw string) (string, bool) {
+    raw = strings.TrimSpace(raw)
+    scheme, rest := "", raw
+    if idx := strings.Index(raw, "://"); idx != -1 {
+        scheme, rest = strings.ToLower(raw[:idx]), raw[idx+3:]
+        if scheme != "http" && scheme != "https" {
+            return "", false
+        }
+    }
+
+    u, err := url.Parse("placeholder://" + rest)
+    if err != nil || u.Hostname() == "" {
+        return "", false
+    }
+
+    // Host: lowercase, no trailing dot, no wildcard labels, punycode
+    host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+    for strings.HasPrefix(host, "*.") {
+        host = host[2:]
+    }
+    if host == "" || strings.Contains(host, "*") {
+        return "", false
+    }
+    if net.ParseIP(host) == nil && !isASCII(host) {
+        ascii, err := idnaProfile.ToASCII(host)
+        if err != nil {
+            return "", false
+        }
+        host = ascii
+    }
+
+    // Port: drop it when it is the default for an explicit scheme
+    port := u.Port()
+    if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+        port = ""
+    }
+    hostPort := host
+    if port != "" {
+        hostPort = net.JoinHostPort(host, port)
+    } else if strings.Contains(host, ":") {
+        hostPort = "[" + host + "]"
+    }
+
+    result := hostPort + canonicalPath(u.EscapedPath())
+    if u.RawQuery != "" {
+        result += "?" + u.RawQuery
+    }
+    if scheme != "" {
+        result = scheme + "://" + result
+    }
+    return result, true
+}
+
+// canonicalPath resolves dot segments and duplicate slashes; the root path is
+// dropped and a trailing slash on deeper paths is kept
+func canonicalPath(p string) string {
+    if p == "" || p == "/" {
+        return ""
+    }
+    cleaned := path.Clean("/" + p)
+    if cleaned == "/" {
+        return ""
+    }
+    if strings.HasSuffix(p, "/") {
+        cleaned += "/"
+    }
+    return cleaned
+}
+
+// isASCII reports whether s contains only ASCII characters
+func isASCII(s string) bool {
+    for i := 0; i < len(s); i++ {
+        if s[i] >= 0x80 {
+            return false
+        }
+    }
+    return true
+}
+
+// deduper tracks seen targets exactly until DEDUP_EXACT_LIMIT, then moves to a
+// fixed-size Bloom filter. Only the seen set is bounded; the targets themselves
+// are still held in memory by the caller
+type deduper struct {
+    exact map[string]struct{}
+    bloom []uint64
+    limit int // Exact entries before the switch, DEDUP_EXACT_LIMIT
+}
+
+// newDeduper creates an empty deduper in exact mode
+func newDeduper() *deduper {
+    return &deduper{exact: make(map[string]struct{}), limit: DEDUP_EXACT_LIMIT}
+}
+
+// Seen records key and reports whether it was already present
+func (d *deduper) Seen(key string) bool {
+    if d.bloom != nil {
+        return d.bloomAdd(key)
+    }
+
+    if _, ok := d.exact[key]; ok {
+        return true
+    }
+    d.exact[key] = struct{}{}
+
+    // Switch to the Bloom filter once the exact set grows too large
+    if len(d.exact) >= d.limit {
+        d.bloom = make([]uint64, BLOOM_BITS/64)
+        for k := range d.exact {
+            d.bloomAdd(k)
+        }
+        d.exact = nil
+    }
+    return false
+}
+
+// bloomAdd sets the key's bits and reports whether they were all already set
+func (d *deduper) bloomAdd(key string) bool {
+    h := fnv.New64a()
+    h.Write([]byte(key))
+    sum := h.Sum64()
+    h1, h2 := uint32(sum), uint32(sum>>32)|1
+
+    present := true
+    for i := uint32(0); i < BLOOM_HASHES; i++ {
+        bit := (h1 + i*h2) % BLOOM_BITS
+        word, mask := bit/64, uint64(1)<<(bit%64)
+        if d.bloom[word]&mask == 0 {
+            present = false
+            d.bloom[word] |= mask
+        }
+    }
+    return present
+}
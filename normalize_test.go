@@ -0,0 +1,104 @@
+package main
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+)
+
+func TestNormalizeTarget(t *testing.T) {
+    tests := []struct {
+        input  string
+        want   string
+        wantOK bool
+    }{
+        {"Example.COM", "example.com", true},
+        {"  example.com.  ", "example.com", true},
+        {"*.example.com", "example.com", true},
+        {"*.*.example.com", "example.com", true},
+        {"bücher.example", "xn--bcher-kva.example", true},
+        {"https://Bücher.Example/", "https://xn--bcher-kva.example", true},
+        {"under_score.example.com", "under_score.example.com", true},
+        // Default ports go only when the scheme says which port is the default
+        {"https://example.com:443/", "https://example.com", true},
+        {"http://example.com:80", "http://example.com", true},
+        {"https://example.com:80", "https://example.com:80", true},
+        {"example.com:443", "example.com:443", true},
+        {"HTTPS://example.com:8443", "https://example.com:8443", true},
+        // Paths: dot segments and duplicate slashes, trailing slash kept below the root
+        {"http://example.com/a/../b//c/", "http://example.com/b/c/", true},
+        {"example.com/./admin", "example.com/admin", true},
+        {"example.com/..", "example.com", true},
+        {"https://example.com/search?q=a+b", "https://example.com/search?q=a+b", true},
+        {"https://[2001:DB8::1]:443/", "https://[2001:db8::1]", true},
+        {"[2001:db8::1]:8443", "[2001:db8::1]:8443", true},
+        {"192.0.2.1", "192.0.2.1", true},
+        {"", "", false},
+        {"ftp://example.com", "", false},
+        {"a.*.example.com", "", false},
+        {"*.", "", false},
+        {"https://", "", false},
+    }
+    for _, tt := range tests {
+        got, ok := normalizeTarget(tt.input)
+        if got != tt.want || ok != tt.wantOK {
+            t.Errorf("normalizeTarget(%q) = %q, %t; want %q, %t", tt.input, got, ok, tt.want, tt.wantOK)
+        }
+    }
+}
+
+func TestNormalizeTargets(t *testing.T) {
+    targets := []Target{
+        {URL: "Example.com", Meta: map[string]interface{}{"source": "first"}},
+        {URL: "https://a.example.com:443/"},
+        {URL: "example.com.", Meta: map[string]interface{}{"source": "second"}},
+        {URL: "ftp://example.com"},
+        {URL: "https://a.example.com"},
+        {URL: "http://a.example.com"},
+    }
+    got, stats := normalizeTargets(targets)
+    want := []Target{
+        {URL: "example.com", Meta: map[string]interface{}{"source": "first"}}, // The first occurrence keeps its metadata
+        {URL: "https://a.example.com"},
+        {URL: "http://a.example.com"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("targets = %+v, want %+v", got, want)
+    }
+    if stats != (NormalizeStats{Input: 6, Duplicates: 2, Invalid: 1}) {
+        t.Errorf("stats = %+v", stats)
+    }
+}
+
+func TestDeduperSwitchesToBloom(t *testing.T) {
+    d := newDeduper()
+    d.limit = 100
+    for i := 0; i < 100; i++ {
+        if d.Seen(fmt.Sprintf("host%d.example.com", i)) {
+            t.Fatalf("host%d reported seen on first insert", i)
+        }
+    }
+    if d.bloom == nil || d.exact != nil {
+        t.Fatal("deduper did not switch to the Bloom filter at its limit")
+    }
+
+    // Keys from the exact set carry over, new ones are added
+    for i := 0; i < 100; i++ {
+        if !d.Seen(fmt.Sprintf("host%d.example.com", i)) {
+            t.Errorf("host%d forgotten after the switch", i)
+        }
+    }
+    falsePositives := 0
+    for i := 100; i < 10100; i++ {
+        key := fmt.Sprintf("host%d.example.com", i)
+        if d.Seen(key) {
+            falsePositives++
+        }
+        if !d.Seen(key) {
+            t.Fatalf("%s not seen on second insert", key)
+        }
+    }
+    if falsePositives > 10 {
+        t.Errorf("%d false positives in 10000 new keys", falsePositives)
+    }
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "text/template"
+    "time"
+    "unicode/utf8"
+)
+
+const (
+    NOTIFY_BATCH_SIZE     = 20               // Results per notification
+    NOTIFY_RETRIES        = 3                // Attempts per notification
+    NOTIFY_BACKOFF        = 1 * time.Second  // First retry delay, doubled each attempt
+    NOTIFY_MAX_BACKOFF    = 30 * time.Second // Longest retry delay, also caps Retry-After
+    NOTIFY_QUEUE_SIZE     = 100              // Notifications waiting to be sent
+    DISCORD_MAX_CONTENT   = 2000             // Discord message length limit
+    DEFAULT_NOTIFY_FILTER = ".Alive"         // Notify about every live result shown
+)
+
+// Notification kinds
+const (
+    NotifyWebhook = "webhook"
+    NotifySlack   = "slack"
+    NotifyDiscord = "discord"
+)
+
+// Notification events
+const (
+    EventResults      = "results"
+    EventScanComplete = "scan_complete"
+)
+
+// NotifyOptions configures where and when notifications are sent
+type NotifyOptions struct {
+    Webhook  string // Generic webhook URL (JSON POST)
+    Template string // text/template for the generic webhook body
+    Slack    string // Slack incoming webhook URL
+    Discord  string // Discord webhook URL
+    Filter   string // Template expression selecting results to notify about
+    Batch    int    // Results per notification
+}
+
+// Enabled reports whether any notification target is configured
+func (no NotifyOptions) Enabled() bool {
+    return no.Webhook != "" || no.Slack != "" || no.Discord != ""
+}
+
+// NotifyEvent is sent to webhooks and rendered for chat targets
+type NotifyEvent struct {
+    Event   string    `json:"event"`
+    Time    time.Time `json:"time"`
+    Results []*Result `json:"results,omitempty"`
+    Summary string    `json:"summary,omitempty"`
+}
+
+// notifyTarget is one destination with its payload style
+type notifyTarget struct {
+    kind string
+    url  string
+}
+
+// Notifier batches matching results and delivers them in the background
+type Notifier struct {
+    targets []notifyTarget
+    filter  *template.Template
+    body    *template.Template // Generic webhook body, nil for plain JSON
+    lines   *template.Template // One chat line per result
+    batch   int
+    pending []*Result
+    queue   chan *NotifyEvent
+    client  *http.Client
+    wg      sync.WaitGroup
+    dropped int // Events dropped because the queue was full
+
+    backoff    time.Duration // First retry delay
+    maxBackoff time.Duration // Longest retry delay
+}
+
+// NewNotifier parses the filter and body templates and starts the sender
+func NewNotifier(opts NotifyOptions, config *Config) (*Notifier, error) {
+    n := &Notifier{
+        batch:  opts.Batch,
+        queue:  make(chan *NotifyEvent, NOTIFY_QUEUE_SIZE),
+        client: &http.Client{Timeout: 10 * time.Second},
+
+        backoff:    NOTIFY_BACKOFF,
+        maxBackoff: NOTIFY_MAX_BACKOFF,
+    }
+    if n.batch <= 0 {
+        n.batch = NOTIFY_BATCH_SIZE
+    }
+    if opts.Webhook != "" {
+        n.targets = append(n.targets, notifyTarget{NotifyWebhook, opts.Webhook})
+    }
+    if opts.Slack != "" {
+        n.targets = append(n.targets, notifyTarget{NotifySlack, opts.Slack})
+    }
+    if opts.Discord != "" {
+        n.targets = append(n.targets, notifyTarget{NotifyDiscord, opts.Discord})
+    }
+
+    // The filter may be written with or without the template braces
+    filter := opts.Filter
+    if filter == "" {
+        filter = DEFAULT_NOTIFY_FILTER
+    }
+    if !strings.Contains(filter, "{{") {
+        filter = "{{" + filter + "}}"
+    }
+
+    funcs := templateFuncs(config)
+    var err error
+    if n.filter, err = template.New("filter").Funcs(funcs).Parse(filter); err != nil {
+        return nil, fmt.Errorf("invalid -notify-filter: %v", err)
+    }
+    if opts.Template != "" {
+        if n.body, err = template.New("body").Funcs(funcs).Parse(opts.Template); err != nil {
+            return nil, fmt.Errorf("invalid -notify-template: %v", err)
+        }
+    }
+    n.lines = template.Must(template.New("line").Funcs(funcs).Parse(TemplateDetailed))
+
+    n.wg.Add(1)
+    go n.sender()
+    return n, nil
+}
+
+// Format queues the result when it matches the filter
+func (n *Notifier) Format(result *Result) error {
+    var out bytes.Buffer
+    if err := n.filter.Execute(&out, result); err != nil {
+        return fmt.Errorf("notify filter: %v", err)
+    }
+    if strings.TrimSpace(out.String()) != "true" {
+        return nil
+    }
+
+    n.pending = append(n.pending, result)
+    if len(n.pending) >= n.batch {
+        n.flush()
+    }
+    return nil
+}
+
+// ScanComplete sends the remaining results followed by the scan summary
+func (n *Notifier) ScanComplete(summary string) {
+    n.flush()
+    n.enqueue(&NotifyEvent{Event: EventScanComplete, Time: time.Now(), Summary: summary})
+}
+
+// Close flushes pending results and waits for queued notifications
+func (n *Notifier) Close() error {
+    n.flush()
+    close(n.queue)
+    n.wg.Wait()
+    if n.dropped > 0 {
+        fmt.Fprintf(os.Stderr, "%d notification(s) dropped, the notification queue was full\n", n.dropped)
+    }
+    return nil
+}
+
+// flush queues pending results as one notification
+func (n *Notifier) flush() {
+    if len(n.pending) == 0 {
+        return
+    }
+    n.enqueue(&NotifyEvent{Event: EventResults, Time: time.Now(), Results: n.pending})
+    n.pending = nil
+}
+
+// enqueue hands an event to the sender without waiting: a slow or failing
+// target must not hold up the scan, so the event is dropped when the queue
+// is full
+func (n *Notifier) enqueue(event *NotifyEvent) {
+    select {
+    case n.queue <- event:
+    default:
+        n.dropped++
+    }
+}
+
+// sender delivers queued notifications to every target
+func (n *Notifier) sender() {
+    defer n.wg.Done()
+    for event := range n.queue {
+        for _, target := range n.targets {
+            payload, err := n.payload(target.kind, event)
+            if err == nil {
+                err = n.post(target.url, payload)
+            }
+            if err != nil {
+                fmt.Fprintf(os.Stderr, "Error sending %s notification: %v\n", target.kind, err)
+            }
+        }
+    }
+}
+
+// payload builds the request body for a target
+func (n *Notifier) payload(kind string, event *NotifyEvent) ([]byte, error) {
+    switch kind {
+    case NotifySlack:
+        return json.Marshal(map[string]string{"text": n.chatText(event)})
+    case NotifyDiscord:
+        // The limit is in characters; cut on a rune boundary to keep valid UTF-8
+        text := n.chatText(event)
+        if utf8.RuneCountInString(text) > DISCORD_MAX_CONTENT {
+            text = string([]rune(text)[:DISCORD_MAX_CONTENT-3]) + "..."
+        }
+        return json.Marshal(map[string]string{"content": text})
+    default:
+        if n.body == nil {
+            return json.Marshal(event)
+        }
+        var body bytes.Buffer
+        if err := n.body.Execute(&body, event); err != nil {
+            return nil, fmt.Errorf("notify template: %v", err)
+        }
+        return body.Bytes(), nil
+    }
+}
+
+// chatText renders an event as a short message for Slack and Discord
+func (n *Notifier) chatText(event *NotifyEvent) string {
+    if event.Event == EventScanComplete {
+        return "AliveHunter scan completed: " + event.Summary
+    }
+
+    var b strings.Builder
+    fmt.Fprintf(&b, "AliveHunter: %d finding(s)\n", len(event.Results))
+    for _, result := range event.Results {
+        var line bytes.Buffer
+        if err := n.lines.Execute(&line, result); err != nil || line.Len() == 0 {
+            line.Reset()
+            line.WriteString(result.URL)
+        }
+        b.WriteString(strings.TrimSpace(line.String()) + "\n")
+    }
+    return strings.TrimSpace(b.String())
+}
+
+// post sends the payload, retrying network errors, 429 and 5xx responses
+func (n *Notifier) post(url string, payload []byte) error {
+    backoff := n.backoff
+    var lastErr error
+
+    for attempt := 1; attempt <= NOTIFY_RETRIES; attempt++ {
+        resp, err := n.client.Post(url, "application/json", bytes.NewReader(payload))
+        if err != nil {
+            lastErr = err
+        } else {
+            io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+            resp.Body.Close()
+
+            if resp.StatusCode < 300 {
+                return nil
+            }
+            lastErr = fmt.Errorf("webhook returned %s", resp.Status)
+            if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+                return lastErr
+            }
+            // Rate limited: honour Retry-After when the receiver sends it,
+            // up to maxBackoff so a broken receiver can't stall the sender
+            if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+                backoff = time.Duration(seconds) * time.Second
+            }
+        }
+
+        if attempt < NOTIFY_RETRIES {
+            if backoff > n.maxBackoff {
+                backoff = n.maxBackoff
+            }
+            time.Sleep(backoff)
+            backoff *= 2
+        }
+    }
+    return lastErr
+}
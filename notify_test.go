@@ -0,0 +1,215 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+    "unicode/utf8"
+)
+
+// receiver records the bodies posted to it and answers with the given
+// responses in turn, then 200
+type receiver struct {
+    *httptest.Server
+    mu        sync.Mutex
+    bodies    [][]byte
+    responses []func(w http.ResponseWriter)
+}
+
+func newReceiver(t *testing.T, responses ...func(w http.ResponseWriter)) *receiver {
+    rc := &receiver{responses: responses}
+    rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        rc.mu.Lock()
+        rc.bodies = append(rc.bodies, body)
+        var respond func(w http.ResponseWriter)
+        if len(rc.responses) > 0 {
+            respond, rc.responses = rc.responses[0], rc.responses[1:]
+        }
+        rc.mu.Unlock()
+        if respond != nil {
+            respond(w)
+        }
+    }))
+    t.Cleanup(rc.Close)
+    return rc
+}
+
+func (rc *receiver) received() [][]byte {
+    rc.mu.Lock()
+    defer rc.mu.Unlock()
+    return rc.bodies
+}
+
+func respondWith(code int, retryAfter string) func(w http.ResponseWriter) {
+    return func(w http.ResponseWriter) {
+        if retryAfter != "" {
+            w.Header().Set("Retry-After", retryAfter)
+        }
+        w.WriteHeader(code)
+    }
+}
+
+// newTestNotifier creates a notifier with short retry delays
+func newTestNotifier(t *testing.T, opts NotifyOptions) *Notifier {
+    t.Helper()
+    n, err := NewNotifier(opts, defaultConfig())
+    if err != nil {
+        t.Fatal(err)
+    }
+    n.backoff = 10 * time.Millisecond
+    n.maxBackoff = 50 * time.Millisecond
+    return n
+}
+
+func TestNotifierBatchingAndDefaultFilter(t *testing.T) {
+    rc := newReceiver(t)
+    n := newTestNotifier(t, NotifyOptions{Webhook: rc.URL, Batch: 2})
+
+    for i := 1; i <= 6; i++ {
+        // Only the odd ones are alive and pass the default .Alive filter
+        n.Format(&Result{URL: fmt.Sprintf("https://%d.example.com", i), Status: 200, Alive: i%2 == 1})
+    }
+    n.ScanComplete("Checked: 6")
+    n.Close()
+
+    var events []NotifyEvent
+    for _, body := range rc.received() {
+        var event NotifyEvent
+        if err := json.Unmarshal(body, &event); err != nil {
+            t.Fatalf("invalid webhook body %s: %v", body, err)
+        }
+        events = append(events, event)
+    }
+    if len(events) != 3 {
+        t.Fatalf("got %d events, want 3", len(events))
+    }
+    for i, want := range [][]string{{"https://1.example.com", "https://3.example.com"}, {"https://5.example.com"}} {
+        if events[i].Event != EventResults || len(events[i].Results) != len(want) {
+            t.Fatalf("event %d = %+v, want %d results", i, events[i], len(want))
+        }
+        for j, url := range want {
+            if events[i].Results[j].URL != url {
+                t.Errorf("event %d result %d = %s, want %s", i, j, events[i].Results[j].URL, url)
+            }
+        }
+    }
+    if events[2].Event != EventScanComplete || events[2].Summary != "Checked: 6" {
+        t.Errorf("last event = %+v, want scan_complete", events[2])
+    }
+}
+
+func TestNotifierCustomFilterAndTemplate(t *testing.T) {
+    rc := newReceiver(t)
+    n := newTestNotifier(t, NotifyOptions{
+        Webhook:  rc.URL,
+        Filter:   `eq .Status 403`,
+        Template: `{{range .Results}}{{.URL}};{{end}}`,
+    })
+    n.Format(&Result{URL: "https://a.example.com", Status: 200, Alive: true})
+    n.Format(&Result{URL: "https://b.example.com", Status: 403, Alive: true})
+    n.Close()
+
+    bodies := rc.received()
+    if len(bodies) != 1 || string(bodies[0]) != "https://b.example.com;" {
+        t.Errorf("bodies = %q, want one for b.example.com", bodies)
+    }
+}
+
+func TestNotifierChatPayloads(t *testing.T) {
+    slack := newReceiver(t)
+    discord := newReceiver(t)
+    n := newTestNotifier(t, NotifyOptions{Slack: slack.URL, Discord: discord.URL})
+
+    // Enough multi-byte paths to go past Discord's limit
+    for i := 0; i < 60; i++ {
+        n.Format(&Result{URL: fmt.Sprintf("https://%d.example.com/%s", i, strings.Repeat("é", 100)), Status: 200, Alive: true})
+    }
+    n.Close()
+
+    var slackMsg map[string]string
+    if bodies := slack.received(); len(bodies) != 3 || json.Unmarshal(bodies[0], &slackMsg) != nil {
+        t.Fatalf("slack got %d bodies, want 3 JSON batches", len(bodies))
+    }
+    if !strings.HasPrefix(slackMsg["text"], "AliveHunter: 20 finding(s)\nhttps://0.example.com") {
+        t.Errorf("slack text = %q", slackMsg["text"])
+    }
+
+    var discordMsg map[string]string
+    if bodies := discord.received(); len(bodies) != 3 || json.Unmarshal(bodies[0], &discordMsg) != nil {
+        t.Fatalf("discord got %d bodies, want 3 JSON batches", len(bodies))
+    }
+    content := discordMsg["content"]
+    if !utf8.ValidString(content) || utf8.RuneCountInString(content) != DISCORD_MAX_CONTENT || !strings.HasSuffix(content, "...") {
+        t.Errorf("discord content: valid UTF-8 %t, %d characters; want %d ending in ...",
+            utf8.ValidString(content), utf8.RuneCountInString(content), DISCORD_MAX_CONTENT)
+    }
+}
+
+func TestNotifierRetry(t *testing.T) {
+    tests := []struct {
+        name      string
+        responses []func(w http.ResponseWriter)
+        attempts  int
+        wantErr   bool
+    }{
+        {"success", nil, 1, false},
+        {"5xx then success", []func(w http.ResponseWriter){respondWith(503, ""), respondWith(502, "")}, 3, false},
+        {"429 then success", []func(w http.ResponseWriter){respondWith(429, "1")}, 2, false},
+        {"client error", []func(w http.ResponseWriter){respondWith(400, "")}, 1, true},
+        {"retries exhausted", []func(w http.ResponseWriter){respondWith(500, ""), respondWith(500, ""), respondWith(500, "")}, 3, true},
+        // A huge Retry-After is capped at maxBackoff
+        {"retry-after capped", []func(w http.ResponseWriter){respondWith(429, "86400")}, 2, false},
+    }
+    for _, tt := range tests {
+        rc := newReceiver(t, tt.responses...)
+        n := newTestNotifier(t, NotifyOptions{Webhook: rc.URL})
+
+        start := time.Now()
+        err := n.post(rc.URL, []byte(`{}`))
+        elapsed := time.Since(start)
+        n.Close()
+
+        if (err != nil) != tt.wantErr {
+            t.Errorf("%s: error = %v, want error %t", tt.name, err, tt.wantErr)
+        }
+        if got := len(rc.received()); got != tt.attempts {
+            t.Errorf("%s: %d attempts, want %d", tt.name, got, tt.attempts)
+        }
+        if elapsed > 2*time.Second {
+            t.Errorf("%s: took %v", tt.name, elapsed)
+        }
+    }
+}
+
+func TestNotifierDropsWhenQueueFull(t *testing.T) {
+    // The receiver hangs until the test ends, so the queue fills up
+    release := make(chan struct{})
+    rc := newReceiver(t, func(w http.ResponseWriter) { <-release })
+    n := newTestNotifier(t, NotifyOptions{Webhook: rc.URL, Batch: 1})
+
+    done := make(chan struct{})
+    go func() {
+        for i := 0; i < NOTIFY_QUEUE_SIZE+10; i++ {
+            n.Format(&Result{URL: fmt.Sprintf("https://%d.example.com", i), Alive: true})
+        }
+        n.ScanComplete("Checked: many")
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-time.After(5 * time.Second):
+        t.Fatal("Format blocked on a stalled webhook")
+    }
+    if n.dropped == 0 {
+        t.Error("no notifications dropped with a full queue")
+    }
+    close(release)
+    n.Close()
+}
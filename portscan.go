@@ -0,0 +1,294 @@
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "net"
+    "strconv"
+    "strings"
+)
+
+// Input formats accepted by -input-format
+const (
+    InputFormatLines   = "lines"
+    InputFormatJSONL   = "jsonl"
+    InputFormatNmap    = "nmap"
+    InputFormatMasscan = "masscan"
+    InputFormatNaabu   = "naabu"
+)
+
+// Ports commonly serving TLS or plaintext HTTP, used when no service name is known
+var (
+    tlsPorts   = map[int]bool{443: true, 4443: true, 8443: true, 9443: true, 10443: true}
+    plainPorts = map[int]bool{80: true, 8000: true, 8008: true, 8080: true, 8081: true, 8888: true}
+)
+
+// portTarget is a single open host:port reported by a scanner
+type portTarget struct {
+    Host    string
+    Port    int
+    Service string
+    TLS     bool
+}
+
+// String renders the target with the guessed scheme; unknown schemes are left
+// bare so CheckURL tries both protocols
+func (pt portTarget) String() string {
+    hostPort := net.JoinHostPort(pt.Host, strconv.Itoa(pt.Port))
+    switch guessScheme(pt) {
+    case "https":
+        return "https://" + hostPort
+    case "http":
+        return "http://" + hostPort
+    }
+    return hostPort
+}
+
+// guessScheme picks https/http from the service name, TLS flag or port number
+func guessScheme(pt portTarget) string {
+    service := strings.ToLower(pt.Service)
+    switch {
+    case pt.TLS, service == "https", service == "https-alt", strings.HasPrefix(service, "ssl/"), strings.HasPrefix(service, "tls/"):
+        return "https"
+    case strings.HasPrefix(service, "http"):
+        return "http"
+    case tlsPorts[pt.Port]:
+        return "https"
+    case plainPorts[pt.Port]:
+        return "http"
+    }
+    return ""
+}
+
+// parsePortScanInput dispatches to the parser for the given scanner format
+func parsePortScanInput(r io.Reader, format string) ([]string, error) {
+    var targets []portTarget
+    var err error
+
+    switch format {
+    case InputFormatNmap:
+        targets, err = parseNmapXML(r)
+    case InputFormatMasscan:
+        targets, err = parseMasscan(r)
+    case InputFormatNaabu:
+        targets, err = parseNaabu(r)
+    default:
+        return nil, fmt.Errorf("unknown input format: %s", format)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var urls []string
+    seen := make(map[string]bool)
+    for _, target := range targets {
+        url := target.String()
+        if !seen[url] {
+            seen[url] = true
+            urls = append(urls, url)
+        }
+    }
+    return urls, nil
+}
+
+// nmapRun mirrors the parts of nmap -oX output we need
+type nmapRun struct {
+    Hosts []struct {
+        Addresses []struct {
+            Addr     string `xml:"addr,attr"`
+            AddrType string `xml:"addrtype,attr"`
+        } `xml:"address"`
+        Hostnames []struct {
+            Name string `xml:"name,attr"`
+            Type string `xml:"type,attr"`
+        } `xml:"hostnames>hostname"`
+        Ports []struct {
+            Protocol string `xml:"protocol,attr"`
+            PortID   int    `xml:"portid,attr"`
+            State    struct {
+                State string `xml:"state,attr"`
+            } `xml:"state"`
+            Service struct {
+                Name   string `xml:"name,attr"`
+                Tunnel string `xml:"tunnel,attr"`
+            } `xml:"service"`
+        } `xml:"ports>port"`
+    } `xml:"host"`
+}
+
+// parseNmapXML extracts open TCP ports from nmap -oX output
+func parseNmapXML(r io.Reader) ([]portTarget, error) {
+    var run nmapRun
+    if err := xml.NewDecoder(r).Decode(&run); err != nil {
+        return nil, fmt.Errorf("invalid nmap XML: %v", err)
+    }
+
+    var targets []portTarget
+    for _, host := range run.Hosts {
+        // Prefer the name that was scanned, then PTR, then the address
+        name := ""
+        for _, hostname := range host.Hostnames {
+            if hostname.Type == "user" || name == "" {
+                name = hostname.Name
+            }
+        }
+        if name == "" {
+            for _, addr := range host.Addresses {
+                if addr.AddrType == "ipv4" || addr.AddrType == "ipv6" {
+                    name = addr.Addr
+                    break
+                }
+            }
+        }
+        if name == "" {
+            continue
+        }
+
+        for _, port := range host.Ports {
+            if port.State.State != "open" || (port.Protocol != "" && port.Protocol != "tcp") {
+                continue
+            }
+            service := port.Service.Name
+            if port.Service.Tunnel == "ssl" {
+                service = "ssl/" + service
+            }
+            targets = append(targets, portTarget{Host: name, Port: port.PortID, Service: service})
+        }
+    }
+    return targets, nil
+}
+
+// masscanRecord is one host entry in masscan -oJ output
+type masscanRecord struct {
+    IP    string `json:"ip"`
+    Ports []struct {
+        Port    int    `json:"port"`
+        Proto   string `json:"proto"`
+        Status  string `json:"status"`
+        Service struct {
+            Name string `json:"name"`
+        } `json:"service"`
+    } `json:"ports"`
+}
+
+// parseMasscan handles both masscan -oJ and -oL output
+func parseMasscan(r io.Reader) ([]portTarget, error) {
+    data, err := io.ReadAll(r)
+    if err != nil {
+        return nil, err
+    }
+
+    trimmed := bytes.TrimSpace(data)
+    if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+        return parseMasscanJSON(trimmed)
+    }
+    return parseMasscanList(trimmed)
+}
+
+// parseMasscanJSON reads -oJ output line by line, since masscan emits
+// trailing commas that a strict decoder of the whole array rejects
+func parseMasscanJSON(data []byte) ([]portTarget, error) {
+    var targets []portTarget
+    scanner := bufio.NewScanner(bytes.NewReader(data))
+    scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
+
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        line = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+        line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+        // Skip brackets and the non-JSON {finished: 1} trailer
+        if !strings.HasPrefix(line, "{") || !strings.Contains(line, `"ip"`) {
+            continue
+        }
+
+        var record masscanRecord
+        if err := json.Unmarshal([]byte(line), &record); err != nil {
+            return nil, fmt.Errorf("invalid masscan JSON: %v", err)
+        }
+        for _, port := range record.Ports {
+            if record.IP == "" || (port.Status != "" && port.Status != "open") || (port.Proto != "" && port.Proto != "tcp") {
+                continue
+            }
+            targets = append(targets, portTarget{Host: record.IP, Port: port.Port, Service: port.Service.Name})
+        }
+    }
+    return targets, scanner.Err()
+}
+
+// parseMasscanList reads -oL output: "open tcp 443 1.2.3.4 1700000000"
+func parseMasscanList(data []byte) ([]portTarget, error) {
+    var targets []portTarget
+    scanner := bufio.NewScanner(bytes.NewReader(data))
+
+    for scanner.Scan() {
+        fields := strings.Fields(scanner.Text())
+        if len(fields) < 4 || fields[0] != "open" || fields[1] != "tcp" {
+            continue
+        }
+        port, err := strconv.Atoi(fields[2])
+        if err != nil {
+            continue
+        }
+        targets = append(targets, portTarget{Host: fields[3], Port: port})
+    }
+    return targets, scanner.Err()
+}
+
+// naabuRecord is one line of naabu -json output; older releases nest the port
+type naabuRecord struct {
+    Host string          `json:"host"`
+    IP   string          `json:"ip"`
+    Port json.RawMessage `json:"port"`
+    TLS  bool            `json:"tls"`
+}
+
+// parseNaabu reads naabu -json output (JSON lines)
+func parseNaabu(r io.Reader) ([]portTarget, error) {
+    var targets []portTarget
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
+
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if !strings.HasPrefix(line, "{") {
+            continue
+        }
+
+        var record naabuRecord
+        if err := json.Unmarshal([]byte(line), &record); err != nil {
+            return nil, fmt.Errorf("invalid naabu JSON: %v", err)
+        }
+
+        port, tls := record.portNumber()
+        host := record.Host
+        if host == "" {
+            host = record.IP
+        }
+        if host == "" || port == 0 {
+            continue
+        }
+        targets = append(targets, portTarget{Host: host, Port: port, TLS: record.TLS || tls})
+    }
+    return targets, scanner.Err()
+}
+
+// portNumber decodes either "port":443 or "port":{"Port":443,"TLS":true}
+func (nr naabuRecord) portNumber() (int, bool) {
+    var port int
+    if err := json.Unmarshal(nr.Port, &port); err == nil {
+        return port, false
+    }
+
+    var nested struct {
+        Port int  `json:"Port"`
+        TLS  bool `json:"TLS"`
+    }
+    if err := json.Unmarshal(nr.Port, &nested); err == nil {
+        return nested.Port, nested.TLS
+    }
+    return 0, false
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestParsePortScanInput(t *testing.T) {
+    tests := []struct {
+        file   string
+        format string
+        want   []string
+    }{
+        {
+            file:   "nmap.xml",
+            format: InputFormatNmap,
+            // The user-supplied name wins over PTR; closed and UDP ports are skipped
+            want: []string{"scanme.example.com:22", "http://scanme.example.com:80", "https://scanme.example.com:8443",
+                "https://[2001:db8::1]:443"},
+        },
+        {
+            file:   "masscan.json",
+            format: InputFormatMasscan,
+            want:   []string{"https://192.0.2.20:443", "http://192.0.2.21:8080", "[2001:db8::2]:9999"},
+        },
+        {
+            file:   "masscan.txt",
+            format: InputFormatMasscan,
+            want:   []string{"http://192.0.2.30:80", "https://192.0.2.30:443", "https://[2001:db8::3]:8443"},
+        },
+        {
+            file:   "naabu.jsonl",
+            format: InputFormatNaabu,
+            // Current releases have a flat port, older ones nest it with the TLS flag
+            want: []string{"https://app.example.com:443", "http://app.example.com:8080", "http://[2001:db8::4]:8000",
+                "https://legacy.example.com:9443", "https://legacy.example.com:7000", "legacy.example.com:7001"},
+        },
+    }
+
+    for _, tt := range tests {
+        file, err := os.Open(filepath.Join("testdata", "portscan", tt.file))
+        if err != nil {
+            t.Fatal(err)
+        }
+        got, err := parsePortScanInput(file, tt.format)
+        file.Close()
+        if err != nil {
+            t.Fatalf("%s: %v", tt.file, err)
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s:\n got %v\nwant %v", tt.file, got, tt.want)
+        }
+        for _, url := range got {
+            if !isValidURL(url) {
+                t.Errorf("%s: %s rejected by isValidURL", tt.file, url)
+            }
+        }
+    }
+}
+
+func TestGuessScheme(t *testing.T) {
+    tests := []struct {
+        target portTarget
+        want   string
+    }{
+        {portTarget{Port: 443}, "https"},
+        {portTarget{Port: 8080}, "http"},
+        {portTarget{Port: 22}, ""},
+        {portTarget{Port: 22, TLS: true}, "https"},
+        {portTarget{Port: 8080, Service: "https-alt"}, "https"},
+        {portTarget{Port: 9000, Service: "ssl/http"}, "https"},
+        {portTarget{Port: 443, Service: "http-proxy"}, "http"}, // The service name beats the port
+        {portTarget{Port: 5000, Service: "ssh"}, ""},
+    }
+    for _, tt := range tests {
+        if got := guessScheme(tt.target); got != tt.want {
+            t.Errorf("guessScheme(%+v) = %q, want %q", tt.target, got, tt.want)
+        }
+    }
+}
+
+func TestIsValidURLIPv6(t *testing.T) {
+    tests := []struct {
+        url  string
+        want bool
+    }{
+        {"[2001:db8::1]:443", true},
+        {"https://[2001:db8::1]/admin", true},
+        {"http://[::1]:8080", true},
+        {"https://[not-an-ip]/", false},
+        {"example.com/[x]", false},
+        {"[2001:db8::1", false},
+    }
+    for _, tt := range tests {
+        if got := isValidURL(tt.url); got != tt.want {
+            t.Errorf("isValidURL(%q) = %t, want %t", tt.url, got, tt.want)
+        }
+    }
+}
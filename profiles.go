@@ -0,0 +1,287 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "github.com/BurntSushi/toml"
+    "gopkg.in/yaml.v3"
+)
+
+// Built-in profile names; -fast and -verify select the first two
+const (
+    ProfileFast    = "fast"
+    ProfileVerify  = "verify"
+    ProfileStealth = "stealth"
+)
+
+// Profile maps flag names (without the dash) to values
+type Profile map[string]interface{}
+
+// builtinProfiles replace the old hard-wired -fast and -verify tuning; a
+// config file profile with the same name takes precedence
+var builtinProfiles = map[string]Profile{
+    ProfileFast: {
+        "fast":    true,
+        "t":       DEFAULT_WORKERS * 2,
+        "rate":    DEFAULT_RATE * 2,
+        "timeout": "1s",
+    },
+    ProfileVerify: {
+        "verify":  true,
+        "t":       DEFAULT_WORKERS / 2,
+        "timeout": "10s",
+    },
+    ProfileStealth: {
+        "t":       10,
+        "rate":    2,
+        "timeout": "10s",
+    },
+}
+
+// Flags that are another name for the same setting
+var flagAliases = map[string]string{
+    "threads": "t",
+}
+
+// repeatableFlag is a flag that may be given several times; config file
+// lists set it once per item instead of comma joined
+type repeatableFlag interface {
+    flag.Value
+    Repeatable()
+}
+
+// ConfigFile holds the top-level settings and named profiles of a config file
+type ConfigFile struct {
+    Path     string
+    Settings Profile
+    Profiles map[string]Profile
+}
+
+// defaultConfigPath returns the auto-loaded config file, or "" if none exists
+func defaultConfigPath() string {
+    dir := os.Getenv("XDG_CONFIG_HOME")
+    if dir == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return ""
+        }
+        dir = filepath.Join(home, ".config")
+    }
+    for _, name := range []string{"config.yaml", "config.yml", "config.toml"} {
+        path := filepath.Join(dir, "alivehunter", name)
+        if _, err := os.Stat(path); err == nil {
+            return path
+        }
+    }
+    return ""
+}
+
+// LoadConfigFile reads a YAML or TOML config file, chosen by extension
+func LoadConfigFile(path string) (*ConfigFile, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("error reading config file: %v", err)
+    }
+
+    raw := map[string]interface{}{}
+    if strings.HasSuffix(strings.ToLower(path), ".toml") {
+        err = toml.Unmarshal(data, &raw)
+    } else {
+        err = yaml.Unmarshal(data, &raw)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+    }
+
+    cf := &ConfigFile{Path: path, Settings: Profile{}, Profiles: map[string]Profile{}}
+    for key, value := range raw {
+        if key != "profiles" {
+            cf.Settings[key] = value
+            continue
+        }
+        profiles, ok := value.(map[string]interface{})
+        if !ok {
+            return nil, fmt.Errorf("%s: profiles must be a map of profile name to settings", path)
+        }
+        for name, settings := range profiles {
+            profile, ok := settings.(map[string]interface{})
+            if !ok {
+                return nil, fmt.Errorf("%s: profile %q must be a map of settings", path, name)
+            }
+            cf.Profiles[name] = profile
+        }
+    }
+    return cf, nil
+}
+
+// profile looks a profile up in the file first, then among the built-ins
+func (cf *ConfigFile) profile(name string) (Profile, bool) {
+    if cf != nil {
+        if profile, ok := cf.Profiles[name]; ok {
+            return profile, true
+        }
+    }
+    profile, ok := builtinProfiles[name]
+    return profile, ok
+}
+
+// profileNames lists the profiles available for -profile
+func (cf *ConfigFile) profileNames() []string {
+    seen := map[string]bool{}
+    for name := range builtinProfiles {
+        seen[name] = true
+    }
+    if cf != nil {
+        for name := range cf.Profiles {
+            seen[name] = true
+        }
+    }
+    names := make([]string, 0, len(seen))
+    for name := range seen {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// applyConfig layers settings onto the parsed flags: built-in -fast/-verify
+// tuning, then the file's top-level settings, then the -profile; flags given
+// on the command line always win
+func applyConfig(fs *flag.FlagSet, config *Config, cf *ConfigFile, profileName string) error {
+    explicit := map[string]bool{}
+    fs.Visit(func(f *flag.Flag) {
+        explicit[canonicalFlag(f.Name)] = true
+    })
+
+    merged := Profile{}
+    if cf != nil {
+        for key, value := range cf.Settings {
+            merged[canonicalFlag(key)] = value
+        }
+    }
+    if profileName != "" {
+        profile, ok := cf.profile(profileName)
+        if !ok {
+            return fmt.Errorf("unknown profile %q (available: %s)", profileName, strings.Join(cf.profileNames(), ", "))
+        }
+        for key, value := range profile {
+            merged[canonicalFlag(key)] = value
+        }
+    }
+    if err := applySettings(fs, merged, explicit); err != nil {
+        return err
+    }
+
+    // Mode tuning sits beneath everything set so far
+    skip := map[string]bool{}
+    for key := range explicit {
+        skip[key] = true
+    }
+    for key := range merged {
+        skip[key] = true
+    }
+    for _, mode := range []struct {
+        enabled bool
+        name    string
+    }{{config.FastMode, ProfileFast}, {config.VerifyMode, ProfileVerify}} {
+        if !mode.enabled {
+            continue
+        }
+        profile, _ := cf.profile(mode.name)
+        if err := applySettings(fs, profile, skip); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// applyProfile applies a built-in profile to a config outside the command
+// line, e.g. for API jobs
+func applyProfile(config *Config, name string) error {
+    fs := flag.NewFlagSet(name, flag.ContinueOnError)
+    scanFlags(fs, config)
+    return applySettings(fs, builtinProfiles[name], nil)
+}
+
+// applySettings sets each flag not in skip from its configured value
+func applySettings(fs *flag.FlagSet, settings Profile, skip map[string]bool) error {
+    keys := make([]string, 0, len(settings))
+    for key := range settings {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys) // Deterministic when a file sets both a flag and its alias
+
+    for _, key := range keys {
+        name := canonicalFlag(key)
+        if skip[name] {
+            continue
+        }
+        switch name {
+        case "config", "profile":
+            return fmt.Errorf("%q cannot be set in a config file", key)
+        }
+        f := fs.Lookup(name)
+        if f == nil {
+            return fmt.Errorf("unknown option %q in config file", key)
+        }
+        if list, ok := settings[key].([]interface{}); ok {
+            if _, repeatable := f.Value.(repeatableFlag); repeatable {
+                for _, item := range list {
+                    value, err := flagValue(item)
+                    if err == nil {
+                        err = fs.Set(name, value)
+                    }
+                    if err != nil {
+                        return fmt.Errorf("option %q: %v", key, err)
+                    }
+                }
+                continue
+            }
+        }
+        value, err := flagValue(settings[key])
+        if err != nil {
+            return fmt.Errorf("option %q: %v", key, err)
+        }
+        if err := fs.Set(name, value); err != nil {
+            return fmt.Errorf("option %q: %v", key, err)
+        }
+    }
+    return nil
+}
+
+// canonicalFlag strips a leading dash and resolves aliases
+func canonicalFlag(name string) string {
+    name = strings.TrimLeft(name, "-")
+    if alias, ok := flagAliases[name]; ok {
+        return alias
+    }
+    return name
+}
+
+// flagValue renders a YAML/TOML value as flag text; lists become comma
+// separated, as -mc and -columns expect
+func flagValue(value interface{}) (string, error) {
+    switch v := value.(type) {
+    case []interface{}:
+        items := make([]string, 0, len(v))
+        for _, item := range v {
+            text, err := flagValue(item)
+            if err != nil {
+                return "", err
+            }
+            items = append(items, text)
+        }
+        return strings.Join(items, ","), nil
+    case map[string]interface{}:
+        return "", fmt.Errorf("expected a value, got a map")
+    case nil:
+        return "", nil
+    default:
+        return fmt.Sprint(v), nil
+    }
+}
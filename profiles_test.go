@@ -0,0 +1,138 @@
+package main
+
+import (
+    "flag"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+const testConfigYAML = `
+threads: 50
+rate: 10
+title: true
+mc: [200, 403]
+match-header: ["X-Debug", "Server: nginx"]
+profiles:
+  quiet:
+    rate: 1
+    timeout: 7s
+`
+
+// Same settings as testConfigYAML, plus a fast profile replacing the built-in one
+const testConfigTOML = `
+threads = 50
+rate = 10
+title = true
+mc = [200, 403]
+match-header = ["X-Debug", "Server: nginx"]
+
+[profiles.quiet]
+rate = 1
+timeout = "7s"
+
+[profiles.fast]
+timeout = "4s"
+`
+
+// writeConfigFile writes a config file into a temporary directory
+func writeConfigFile(t *testing.T, name, content string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), name)
+    if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+    yamlPath := writeConfigFile(t, "config.yaml", testConfigYAML)
+    tomlPath := writeConfigFile(t, "config.toml", testConfigTOML)
+
+    tests := []struct {
+        name        string
+        file        string
+        args        []string
+        profile     string
+        wantWorkers int
+        wantRate    float64
+        wantTimeout time.Duration
+    }{
+        {"file settings", yamlPath, nil, "", 50, 10, DEFAULT_TIMEOUT},
+        {"flag beats file", yamlPath, []string{"-rate", "20"}, "", 50, 20, DEFAULT_TIMEOUT},
+        {"alias on the command line", yamlPath, []string{"-threads", "7"}, "", 7, 10, DEFAULT_TIMEOUT},
+        {"profile beats file", yamlPath, nil, "quiet", 50, 1, 7 * time.Second},
+        {"flag beats profile", yamlPath, []string{"-timeout", "2s"}, "quiet", 50, 1, 2 * time.Second},
+        {"built-in fast under file settings", yamlPath, []string{"-fast"}, "", 50, 10, time.Second},
+        {"built-in fast under flags", yamlPath, []string{"-fast", "-t", "5"}, "", 5, 10, time.Second},
+        {"built-in profile by name", yamlPath, nil, ProfileStealth, 10, 2, 10 * time.Second},
+        {"TOML file settings", tomlPath, nil, "", 50, 10, DEFAULT_TIMEOUT},
+        {"TOML profile", tomlPath, nil, "quiet", 50, 1, 7 * time.Second},
+        {"file profile replaces built-in fast", tomlPath, []string{"-fast"}, "", 50, 10, 4 * time.Second},
+        {"no file", "", []string{"-fast"}, "", DEFAULT_WORKERS * 2, DEFAULT_RATE * 2, time.Second},
+    }
+
+    for _, tt := range tests {
+        config := defaultConfig()
+        fs := flag.NewFlagSet("test", flag.ContinueOnError)
+        scanFlags(fs, config)
+        statusCodes := fs.String("mc", "", "")
+        fs.Var(headerMatcherFlag{&config.Match.Headers}, "match-header", "")
+        if err := fs.Parse(tt.args); err != nil {
+            t.Fatal(err)
+        }
+
+        var cf *ConfigFile
+        if tt.file != "" {
+            var err error
+            if cf, err = LoadConfigFile(tt.file); err != nil {
+                t.Fatalf("%s: %v", tt.name, err)
+            }
+        }
+        if err := applyConfig(fs, config, cf, tt.profile); err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+
+        if config.Workers != tt.wantWorkers || config.Rate != tt.wantRate || config.Timeout != tt.wantTimeout {
+            t.Errorf("%s: workers %d rate %v timeout %v; want %d, %v, %v", tt.name,
+                config.Workers, config.Rate, config.Timeout, tt.wantWorkers, tt.wantRate, tt.wantTimeout)
+        }
+        if tt.file == "" {
+            continue
+        }
+        // Lists: comma joined for plain flags, one Set per item for repeatable ones
+        if !config.ExtractTitle || *statusCodes != "200,403" || len(config.Match.Headers) != 2 ||
+            config.Match.Headers[1].String() != "Server: nginx" {
+            t.Errorf("%s: title %t, mc %q, headers %v", tt.name, config.ExtractTitle, *statusCodes, config.Match.Headers)
+        }
+    }
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+    tests := []struct {
+        name    string
+        content string
+        profile string
+        wantErr string
+    }{
+        {"unknown profile", "rate: 10\n", "missing", `unknown profile "missing"`},
+        {"unknown option", "nonsense: 1\n", "", `unknown option "nonsense"`},
+        {"nested config", "config: other.yaml\n", "", `"config" cannot be set`},
+        {"bad value", "rate: fast\n", "", `option "rate"`},
+    }
+    for _, tt := range tests {
+        cf, err := LoadConfigFile(writeConfigFile(t, "config.yaml", tt.content))
+        if err != nil {
+            t.Fatal(err)
+        }
+        config := defaultConfig()
+        fs := flag.NewFlagSet("test", flag.ContinueOnError)
+        scanFlags(fs, config)
+        err = applyConfig(fs, config, cf, tt.profile)
+        if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+            t.Errorf("%s: error %v, want %q", tt.name, err, tt.wantErr)
+        }
+    }
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+    "context"
+    "crypto/tls"
+    "net"
+    "net/http"
+    "net/url"
+    "strings"
+
+    "github.com/quic-go/quic-go/http3"
+)
+
+// ALPN protocol identifiers reported in Result.Protocols
+const (
+    ProtoHTTP11 = "http/1.1"
+    ProtoH2     = "h2"
+    ProtoH3     = "h3"
+)
+
+// newHTTP3Client creates the QUIC client used for HTTP/3 probes
+func newHTTP3Client(config *Config) *http.Client {
+    return &http.Client{
+        Transport: &http3.Transport{
+            TLSClientConfig: &tls.Config{
+                InsecureSkipVerify: true, // Speed > security for reconnaissance
+                MinVersion:         tls.VersionTLS13,
+            },
+            DisableCompression: true,
+        },
+        Timeout: config.Timeout,
+        CheckRedirect: func(req *http.Request, via []*http.Request) error {
+            return http.ErrUseLastResponse
+        },
+    }
+}
+
+// closeHTTP3Client closes the QUIC connections and the UDP socket held by an
+// HTTP/3 client; the transport is unusable afterwards
+func closeHTTP3Client(client *http.Client) {
+    if transport, ok := client.Transport.(*http3.Transport); ok {
+        transport.Close()
+    }
+}
+
+// alpnName maps a response protocol such as "HTTP/2.0" to its ALPN identifier
+func alpnName(proto string) string {
+    switch proto {
+    case "HTTP/2.0":
+        return ProtoH2
+    case "HTTP/3.0":
+        return ProtoH3
+    }
+    return ProtoHTTP11
+}
+
+// altSvcH3Authority returns the authority advertised for h3 in an Alt-Svc
+// header, e.g. h3=":443" -> ":443"
+func altSvcH3Authority(header string) (string, bool) {
+    for _, entry := range strings.Split(header, ",") {
+        protocol, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+        if !found || protocol != ProtoH3 {
+            continue
+        }
+        // Drop parameters such as ; ma=86400
+        value, _, _ = strings.Cut(value, ";")
+        return strings.Trim(strings.TrimSpace(value), `"`), true
+    }
+    return "", false
+}
+
+// probeHTTP3 attempts a HEAD request over QUIC, at the Alt-Svc advertised
+// authority when one is given, otherwise at the original host and port
+func (ac *AliveHTTPClient) probeHTTP3(ctx context.Context, fullURL, altSvcAuthority string) bool {
+    if ac.h3client == nil || !strings.HasPrefix(fullURL, "https://") {
+        return false
+    }
+
+    target, err := url.Parse(fullURL)
+    if err != nil {
+        return false
+    }
+    if altSvcAuthority != "" {
+        host, port, err := net.SplitHostPort(altSvcAuthority)
+        if err != nil {
+            return false
+        }
+        if host == "" {
+            host = target.Hostname()
+        }
+        target.Host = net.JoinHostPort(host, port)
+    }
+
+    req, err := ac.createRequest(ctx, "HEAD", target.String(), RequestTypeCheck)
+    if err != nil {
+        return false
+    }
+    resp, err := ac.h3client.Do(req)
+    if err != nil {
+        return false
+    }
+    resp.Body.Close()
+    return resp.ProtoMajor == 3
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+    "context"
+    "crypto/tls"
+    "fmt"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/quic-go/quic-go/http3"
+)
+
+func TestAltSvcH3Authority(t *testing.T) {
+    tests := []struct {
+        header    string
+        want      string
+        wantFound bool
+    }{
+        {`h3=":443"; ma=86400`, ":443", true},
+        {`h3-29=":443", h3="alt.example.com:8443"; ma=3600`, "alt.example.com:8443", true},
+        {`h2=":443"`, "", false},
+        {``, "", false},
+    }
+    for _, tt := range tests {
+        got, found := altSvcH3Authority(tt.header)
+        if got != tt.want || found != tt.wantFound {
+            t.Errorf("altSvcH3Authority(%q) = %q, %t; want %q, %t", tt.header, got, found, tt.want, tt.wantFound)
+        }
+    }
+}
+
+// startH3Server serves handler over HTTP/3 on a local UDP port
+func startH3Server(t *testing.T, certs []tls.Certificate, handler http.Handler) int {
+    t.Helper()
+    conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+    if err != nil {
+        t.Fatal(err)
+    }
+    server := &http3.Server{
+        Handler:   handler,
+        TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: certs}),
+    }
+    go server.Serve(conn)
+    t.Cleanup(func() {
+        server.Close()
+        conn.Close()
+    })
+    return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestProbeHTTP2AndHTTP3(t *testing.T) {
+    ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        fmt.Fprint(w, "<html><title>ok</title></html>")
+    })
+
+    // The h3 query parameter sets the advertised authority
+    h2 := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if authority := r.URL.Query().Get("h3"); authority != "" {
+            w.Header().Set("Alt-Svc", fmt.Sprintf(`h3="%s"; ma=60`, authority))
+        }
+        ok.ServeHTTP(w, r)
+    }))
+    h2.EnableHTTP2 = true
+    h2.StartTLS()
+    defer h2.Close()
+
+    h3Port := startH3Server(t, h2.TLS.Certificates, ok)
+
+    config := defaultConfig()
+    config.HTTP2 = true
+    config.HTTP3 = true
+    config.Timeout = time.Second
+    client := NewAliveHTTPClient(config)
+    ctx := context.Background()
+
+    tests := []struct {
+        query      string
+        advertised bool
+        want       []string
+    }{
+        {"", false, []string{ProtoH2}},                                     // Direct attempt at the TCP port finds nothing
+        {fmt.Sprintf("?h3=:%d", h3Port), true, []string{ProtoH2, ProtoH3}}, // Alt-Svc points at the QUIC listener
+        {"?h3=:1", true, []string{ProtoH2}},                                // Advertised but nothing listening
+    }
+    for _, tt := range tests {
+        result, err := client.probe(ctx, h2.URL+"/"+tt.query, config)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !result.Alive || result.H3Advertised != tt.advertised {
+            t.Errorf("%q: alive = %t, h3 advertised = %t; want alive, %t", tt.query, result.Alive, result.H3Advertised, tt.advertised)
+        }
+        if !reflect.DeepEqual(result.Protocols, tt.want) {
+            t.Errorf("%q: protocols = %v, want %v", tt.query, result.Protocols, tt.want)
+        }
+    }
+
+    // Direct attempt at the same host and port when nothing is advertised
+    h3URL := fmt.Sprintf("https://127.0.0.1:%d/", h3Port)
+    if !client.probeHTTP3(ctx, h3URL, "") {
+        t.Error("direct HTTP/3 probe failed")
+    }
+    if client.probeHTTP3(ctx, fmt.Sprintf("http://127.0.0.1:%d/", h3Port), "") {
+        t.Error("HTTP/3 probe of an http:// URL succeeded")
+    }
+
+    // Close releases the UDP socket; the transport does not reopen one
+    client.Close()
+    if client.probeHTTP3(ctx, h3URL, "") {
+        t.Error("HTTP/3 probe succeeded after Close")
+    }
+}
+
+func TestProbeHTTP11WithoutHTTP2(t *testing.T) {
+    server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    server.EnableHTTP2 = true
+    server.StartTLS()
+    defer server.Close()
+
+    // -http2 not set: ALPN offers HTTP/1.1 only and no protocol is reported
+    config := defaultConfig()
+    client := NewAliveHTTPClient(config)
+    defer client.Close()
+    result, err := client.probe(context.Background(), server.URL, config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if result.Protocols != nil {
+        t.Errorf("protocols = %v, want none", result.Protocols)
+    }
+
+    config.HTTP2 = true
+    client2 := NewAliveHTTPClient(config)
+    defer client2.Close()
+    if result, err = client2.probe(context.Background(), server.URL, config); err != nil {
+        t.Fatal(err)
+    }
+    if want := []string{ProtoH2}; !reflect.DeepEqual(result.Protocols, want) {
+        t.Errorf("protocols = %v, want %v", result.Protocols, want)
+    }
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+    "fmt"
+    "html/template"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "sync/atomic"
+    "time"
+)
+
+// Groups shown above the results table, in display order
+var reportGroupNames = []string{"Status", "Server", "Title", "Technology"}
+
+// HTMLReport collects results and writes a self-contained HTML report on Close
+type HTMLReport struct {
+    path    string
+    stats   *Stats
+    results []*Result
+}
+
+// reportRow is one results table row
+type reportRow struct {
+    URL          string
+    Status       string
+    Title        string
+    Server       string
+    Technology   string
+    Length       int64
+    ResponseTime int64
+    Verified     bool
+    Redirect     string
+    Error        string
+    Protocols    string
+}
+
+// reportGroupEntry is one value of a group with the number of results
+type reportGroupEntry struct {
+    Value string
+    Count int
+}
+
+// reportGroup lists the values of one field by frequency
+type reportGroup struct {
+    Name    string
+    Key     string
+    Entries []reportGroupEntry
+}
+
+// reportData is passed to the HTML template
+type reportData struct {
+    Version   string
+    Generated string
+    Elapsed   string
+    Total     int64
+    Checked   uint64
+    Alive     uint64
+    Verified  uint64
+    Errors    uint64
+    Speed     string
+    Groups    []reportGroup
+    Rows      []reportRow
+}
+
+// multiFormatter sends every result to several formatters
+type multiFormatter []ResultFormatter
+
+// Format passes the result to each formatter, returning the first error
+func (mf multiFormatter) Format(result *Result) error {
+    var firstErr error
+    for _, f := range mf {
+        if err := f.Format(result); err != nil && firstErr == nil {
+            firstErr = err
+        }
+    }
+    return firstErr
+}
+
+// Close closes each formatter, returning the first error
+func (mf multiFormatter) Close() error {
+    var firstErr error
+    for _, f := range mf {
+        if err := f.Close(); err != nil && firstErr == nil {
+            firstErr = err
+        }
+    }
+    return firstErr
+}
+
+// NewHTMLReport checks the report path is writable before the scan starts
+func NewHTMLReport(path string, stats *Stats) (*HTMLReport, error) {
+    f, err := os.Create(path)
+    if err != nil {
+        return nil, fmt.Errorf("error creating HTML report: %v", err)
+    }
+    f.Close()
+    return &HTMLReport{path: path, stats: stats}, nil
+}
+
+// Format keeps the result for the report
+func (hr *HTMLReport) Format(result *Result) error {
+    hr.results = append(hr.results, result)
+    return nil
+}
+
+// Close renders the collected results and final stats to the report file
+func (hr *HTMLReport) Close() error {
+    f, err := os.Create(hr.path)
+    if err != nil {
+        return fmt.Errorf("error creating HTML report: %v", err)
+    }
+    defer f.Close()
+
+    if err := reportTemplate.Execute(f, hr.data()); err != nil {
+        return fmt.Errorf("error writing HTML report: %v", err)
+    }
+    return nil
+}
+
+// data builds the template data from the collected results
+func (hr *HTMLReport) data() *reportData {
+    elapsed := time.Since(hr.stats.started)
+    checked := atomic.LoadUint64(&hr.stats.checked)
+    speed := 0.0
+    if elapsed.Seconds() > 0 {
+        speed = float64(checked) / elapsed.Seconds()
+    }
+
+    data := &reportData{
+        Version:   VERSION,
+        Generated: time.Now().Format(time.RFC1123),
+        Elapsed:   elapsed.Round(time.Millisecond).String(),
+        Total:     hr.stats.totalUrls,
+        Checked:   checked,
+        Alive:     atomic.LoadUint64(&hr.stats.alive),
+        Verified:  atomic.LoadUint64(&hr.stats.verified),
+        Errors:    atomic.LoadUint64(&hr.stats.errors),
+        Speed:     fmt.Sprintf("%.0f req/s", speed),
+    }
+
+    counts := make(map[string]map[string]int)
+    for _, name := range reportGroupNames {
+        counts[name] = make(map[string]int)
+    }
+
+    for _, result := range hr.results {
+        row := reportRow{
+            URL:          result.URL,
+            Title:        result.Title,
+            Server:       result.Server,
+            Technology:   resultTechnology(result),
+            Length:       result.Length,
+            ResponseTime: result.ResponseTime.Milliseconds(),
+            Verified:     result.Verified,
+            Redirect:     result.Redirect,
+            Error:        result.Error,
+            Protocols:    strings.Join(result.Protocols, ","),
+        }
+        switch {
+        case result.Status != 0:
+            row.Status = strconv.Itoa(result.Status)
+        case result.Service != "":
+            row.Status = "service"
+            row.Title = result.Banner
+        default:
+            row.Status = "failed"
+        }
+        data.Rows = append(data.Rows, row)
+
+        counts["Status"][row.Status]++
+        counts["Server"][row.Server]++
+        counts["Title"][row.Title]++
+        counts["Technology"][row.Technology]++
+    }
+
+    for _, name := range reportGroupNames {
+        group := reportGroup{Name: name, Key: strings.ToLower(name)}
+        for value, count := range counts[name] {
+            if value != "" {
+                group.Entries = append(group.Entries, reportGroupEntry{Value: value, Count: count})
+            }
+        }
+        sort.Slice(group.Entries, func(i, j int) bool {
+            if group.Entries[i].Count != group.Entries[j].Count {
+                return group.Entries[i].Count > group.Entries[j].Count
+            }
+            return group.Entries[i].Value < group.Entries[j].Value
+        })
+        data.Groups = append(data.Groups, group)
+    }
+    return data
+}
+
+// resultTechnology names the software behind a result: the product from the
+// Server header without its version, or the detected non-HTTP service
+func resultTechnology(result *Result) string {
+    if result.Service != "" {
+        return result.Service
+    }
+    product := strings.Fields(result.Server)
+    if len(product) == 0 {
+        return ""
+    }
+    name, _, _ := strings.Cut(product[0], "/")
+    return strings.ToLower(name)
+}
+
+// Parse the report template once; CSS and JS are embedded so the file works offline
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>AliveHunter Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; background: #fafafa; }
+h1 { margin-bottom: 0; }
+.meta { color: #666; margin-top: 0.3em; }
+.stats { display: flex; flex-wrap: wrap; gap: 1em; margin: 1.5em 0; }
+.stat { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: 0.8em 1.2em; min-width: 7em; }
+.stat b { display: block; font-size: 1.6em; }
+.groups { display: flex; flex-wrap: wrap; gap: 1em; margin-bottom: 1.5em; }
+.group { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: 0.6em 1em; flex: 1 1 14em; max-height: 16em; overflow-y: auto; }
+.group h3 { margin: 0 0 0.4em 0; font-size: 1em; }
+.group a { display: flex; justify-content: space-between; gap: 1em; color: #0366d6; text-decoration: none; cursor: pointer; font-size: 0.9em; }
+.group a:hover { text-decoration: underline; }
+.group a span { color: #666; }
+#filter { width: 100%; padding: 0.5em; font-size: 1em; box-sizing: border-box; margin-bottom: 0.5em; }
+#active { color: #666; margin-bottom: 0.5em; min-height: 1.2em; }
+table { border-collapse: collapse; width: 100%; background: #fff; font-size: 0.9em; }
+th, td { border: 1px solid #ddd; padding: 0.4em 0.6em; text-align: left; vertical-align: top; word-break: break-all; }
+th { background: #f0f0f0; cursor: pointer; user-select: none; white-space: nowrap; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr.failed td { color: #999; }
+.ok { color: #22863a; }
+</style>
+</head>
+<body>
+<h1>AliveHunter Report</h1>
+<p class="meta">Generated {{.Generated}} by AliveHunter v{{.Version}}</p>
+
+<div class="stats">
+<div class="stat"><b>{{.Total}}</b>Targets</div>
+<div class="stat"><b>{{.Checked}}</b>Checked</div>
+<div class="stat"><b>{{.Alive}}</b>Alive</div>
+<div class="stat"><b>{{.Verified}}</b>Verified</div>
+<div class="stat"><b>{{.Errors}}</b>Errors</div>
+<div class="stat"><b>{{.Speed}}</b>Speed</div>
+<div class="stat"><b>{{.Elapsed}}</b>Total time</div>
+</div>
+
+<div class="groups">
+{{range .Groups}}<div class="group"><h3>{{.Name}}</h3>
+{{$key := .Key}}{{range .Entries}}<a data-key="{{$key}}" data-value="{{.Value}}">{{.Value}} <span>{{.Count}}</span></a>
+{{else}}<em>none</em>{{end}}</div>
+{{end}}</div>
+
+<input id="filter" type="search" placeholder="Filter results...">
+<div id="active"></div>
+<table id="results">
+<thead><tr>
+<th data-key="url">URL</th>
+<th data-key="status">Status</th>
+<th data-key="title">Title</th>
+<th data-key="server">Server</th>
+<th data-key="technology">Technology</th>
+<th data-key="length" data-type="number">Length</th>
+<th data-key="time" data-type="number">Time (ms)</th>
+<th data-key="verified">Verified</th>
+<th data-key="protocols">Protocols</th>
+<th data-key="redirect">Redirect / Error</th>
+</tr></thead>
+<tbody>
+{{range .Rows}}<tr{{if eq .Status "failed"}} class="failed"{{end}}>
+<td data-key="url">{{.URL}}</td>
+<td data-key="status">{{.Status}}</td>
+<td data-key="title">{{.Title}}</td>
+<td data-key="server">{{.Server}}</td>
+<td data-key="technology">{{.Technology}}</td>
+<td data-key="length">{{.Length}}</td>
+<td data-key="time">{{.ResponseTime}}</td>
+<td data-key="verified">{{if .Verified}}<span class="ok">yes</span>{{end}}</td>
+<td data-key="protocols">{{.Protocols}}</td>
+<td data-key="redirect">{{if .Redirect}}{{.Redirect}}{{else}}{{.Error}}{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+
+<script>
+(function () {
+  var table = document.getElementById("results");
+  var rows = Array.prototype.slice.call(table.tBodies[0].rows);
+  var filter = document.getElementById("filter");
+  var active = document.getElementById("active");
+  var group = null;
+
+  function cell(row, key) {
+    return row.querySelector('td[data-key="' + key + '"]').textContent;
+  }
+
+  function apply() {
+    var text = filter.value.toLowerCase();
+    rows.forEach(function (row) {
+      var show = row.textContent.toLowerCase().indexOf(text) !== -1;
+      if (show && group) {
+        show = cell(row, group.key) === group.value;
+      }
+      row.style.display = show ? "" : "none";
+    });
+    active.textContent = group ? "Showing " + group.key + " = " + group.value + " (click again to clear)" : "";
+  }
+
+  filter.addEventListener("input", apply);
+
+  document.querySelectorAll(".group a").forEach(function (link) {
+    link.addEventListener("click", function () {
+      var next = { key: link.dataset.key, value: link.dataset.value };
+      group = group && group.key === next.key && group.value === next.value ? null : next;
+      apply();
+    });
+  });
+
+  document.querySelectorAll("th").forEach(function (th) {
+    th.addEventListener("click", function () {
+      var key = th.dataset.key;
+      var numeric = th.dataset.type === "number";
+      var asc = !th.classList.contains("asc");
+      document.querySelectorAll("th").forEach(function (other) { other.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      rows.sort(function (a, b) {
+        var x = cell(a, key), y = cell(b, key);
+        var order = numeric ? Number(x) - Number(y) : x.localeCompare(y, undefined, { numeric: true });
+        return asc ? order : -order;
+      });
+      rows.forEach(function (row) { table.tBodies[0].appendChild(row); });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
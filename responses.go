@@ -0,0 +1,114 @@
+package main
+
+import (
+    "bufio"
+    "crypto/sha1"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    DEFAULT_STORE_RESPONSE_SIZE = 512 * 1024  // Body bytes kept per stored response
+    RESPONSE_INDEX_FILE         = "index.jsonl" // Links stored files to result URLs
+)
+
+// ResponseIndexEntry is one line of the response index
+type ResponseIndexEntry struct {
+    URL    string    `json:"url"`
+    Status int       `json:"status_code"`
+    File   string    `json:"file"` // Relative to the store directory
+    Length int       `json:"stored_bytes"`
+    Time   time.Time `json:"time"`
+}
+
+// ResponseStore writes raw responses to dir/<host>/<sha1 of URL>.txt
+type ResponseStore struct {
+    dir   string
+    limit int64
+    mu    sync.Mutex // Guards the index
+    index *os.File
+}
+
+// NewResponseStore creates the directory and opens the index for appending
+func NewResponseStore(dir string, limit int64) (*ResponseStore, error) {
+    if limit <= 0 {
+        limit = DEFAULT_STORE_RESPONSE_SIZE
+    }
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return nil, fmt.Errorf("error creating response directory: %v", err)
+    }
+    index, err := os.OpenFile(filepath.Join(dir, RESPONSE_INDEX_FILE), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+    if err != nil {
+        return nil, fmt.Errorf("error opening response index: %v", err)
+    }
+    return &ResponseStore{dir: dir, limit: limit, index: index}, nil
+}
+
+// Save writes the request line and headers, the response status line and
+// headers, and the body; it returns the stored file's path
+func (rs *ResponseStore) Save(fullURL string, resp *http.Response, body []byte) (string, error) {
+    sum := sha1.Sum([]byte(fullURL))
+    host := "unknown"
+    if parsed, err := url.Parse(fullURL); err == nil && parsed.Host != "" {
+        host = strings.ReplaceAll(parsed.Host, ":", "_")
+    }
+    name := filepath.Join(host, hex.EncodeToString(sum[:])+".txt")
+    path := filepath.Join(rs.dir, name)
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        return "", err
+    }
+
+    file, err := os.Create(path)
+    if err != nil {
+        return "", err
+    }
+    w := bufio.NewWriter(file)
+    if req := resp.Request; req != nil {
+        fmt.Fprintf(w, "%s %s %s\r\n", req.Method, req.URL.RequestURI(), req.Proto)
+        fmt.Fprintf(w, "Host: %s\r\n", req.URL.Host)
+        req.Header.Write(w)
+        w.WriteString("\r\n")
+    }
+    fmt.Fprintf(w, "%s %s\r\n", resp.Proto, resp.Status)
+    resp.Header.Write(w)
+    w.WriteString("\r\n")
+    if int64(len(body)) > rs.limit {
+        body = body[:rs.limit] // The probe may read more for body matchers and counts
+    }
+    w.Write(body)
+    if err := w.Flush(); err != nil {
+        file.Close()
+        return "", err
+    }
+    if err := file.Close(); err != nil {
+        return "", err
+    }
+
+    entry, _ := json.Marshal(&ResponseIndexEntry{
+        URL:    fullURL,
+        Status: resp.StatusCode,
+        File:   name, // Relative to the store, which may be moved or archived
+        Length: len(body),
+        Time:   time.Now(),
+    })
+    // Written unbuffered so the index is usable while a long scan runs
+    rs.mu.Lock()
+    defer rs.mu.Unlock()
+    if _, err := rs.index.Write(append(entry, '\n')); err != nil {
+        return path, fmt.Errorf("error writing response index: %v", err)
+    }
+    return path, nil
+}
+
+// Close closes the index
+func (rs *ResponseStore) Close() error {
+    return rs.index.Close()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestResponseStoreSave(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("X-Test", "yes")
+        w.WriteHeader(http.StatusForbidden)
+        io.WriteString(w, "0123456789abcdef")
+    }))
+    defer server.Close()
+
+    dir := filepath.Join(t.TempDir(), "responses")
+    store, err := NewResponseStore(dir, 10)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var paths []string
+    for _, fullURL := range []string{server.URL + "/admin?debug=1", server.URL + "/"} {
+        req, _ := http.NewRequest("GET", fullURL, nil)
+        req.Header.Set("User-Agent", "test-agent")
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            t.Fatal(err)
+        }
+        body, _ := io.ReadAll(resp.Body)
+        resp.Body.Close()
+
+        path, err := store.Save(fullURL, resp, body)
+        if err != nil {
+            t.Fatal(err)
+        }
+        paths = append(paths, path)
+    }
+    store.Close()
+
+    // Request line and headers, response status line and headers, then the body cut at the limit
+    data, err := os.ReadFile(paths[0])
+    if err != nil {
+        t.Fatal(err)
+    }
+    request, response, found := strings.Cut(string(data), "\r\n\r\n")
+    if !found {
+        t.Fatalf("no blank line after the request headers:\n%s", data)
+    }
+    host := strings.TrimPrefix(server.URL, "http://")
+    if !strings.HasPrefix(request, "GET /admin?debug=1 HTTP/1.1\r\nHost: "+host+"\r\n") || !strings.Contains(request, "User-Agent: test-agent") {
+        t.Errorf("request part:\n%s", request)
+    }
+    if !strings.HasPrefix(response, "HTTP/1.1 403 Forbidden\r\n") || !strings.Contains(response, "X-Test: yes\r\n") ||
+        !strings.HasSuffix(response, "\r\n\r\n0123456789") {
+        t.Errorf("response part:\n%q", response)
+    }
+    if filepath.Dir(paths[0]) != filepath.Join(dir, strings.ReplaceAll(host, ":", "_")) {
+        t.Errorf("stored at %s, want a per-host directory in %s", paths[0], dir)
+    }
+
+    // The index still resolves after the store is moved
+    moved := filepath.Join(t.TempDir(), "archive")
+    if err := os.Rename(dir, moved); err != nil {
+        t.Fatal(err)
+    }
+    index, err := os.Open(filepath.Join(moved, RESPONSE_INDEX_FILE))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer index.Close()
+    var entries []ResponseIndexEntry
+    scanner := bufio.NewScanner(index)
+    for scanner.Scan() {
+        var entry ResponseIndexEntry
+        if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+            t.Fatal(err)
+        }
+        entries = append(entries, entry)
+    }
+    if len(entries) != 2 {
+        t.Fatalf("%d index entries, want 2", len(entries))
+    }
+    for i, entry := range entries {
+        if filepath.IsAbs(entry.File) || entry.Status != http.StatusForbidden || entry.Length != 10 {
+            t.Errorf("entry %d = %+v, want a relative file, status 403 and 10 bytes", i, entry)
+        }
+        if _, err := os.Stat(filepath.Join(moved, entry.File)); err != nil {
+            t.Errorf("entry %d: %v", i, err)
+        }
+    }
+    if entries[0].URL != server.URL+"/admin?debug=1" {
+        t.Errorf("first entry URL = %s", entries[0].URL)
+    }
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+    "context"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// SchemeOutcome is what a single scheme answered, without following redirects
+type SchemeOutcome struct {
+    URL      string `json:"url"`
+    Status   int    `json:"status_code,omitempty"`
+    Redirect string `json:"redirect,omitempty"`
+    Error    string `json:"error,omitempty"`
+}
+
+// SchemeReport compares HTTP and HTTPS for one target
+type SchemeReport struct {
+    HTTP                  *SchemeOutcome `json:"http"`
+    HTTPS                 *SchemeOutcome `json:"https"`
+    UpgradesToHTTPS       bool           `json:"upgrades_to_https"`
+    HSTS                  bool           `json:"hsts"`
+    HSTSMaxAge            int64          `json:"hsts_max_age,omitempty"`
+    HSTSIncludeSubdomains bool           `json:"hsts_include_subdomains,omitempty"`
+    HSTSPreload           bool           `json:"hsts_preload,omitempty"`
+}
+
+// MissingUpgrade reports whether plain HTTP answers without redirecting to HTTPS
+func (sr *SchemeReport) MissingUpgrade() bool {
+    return sr.HTTP.Status != 0 && !sr.UpgradesToHTTPS
+}
+
+// AuditSchemes requests the target over both HTTP and HTTPS and records the
+// upgrade behaviour and HSTS policy
+func (ac *AliveHTTPClient) AuditSchemes(ctx context.Context, rawURL string) *SchemeReport {
+    report := &SchemeReport{}
+    urls := probeURLs(rawURL, ProbeDual)
+
+    for _, fullURL := range urls {
+        outcome, resp := ac.auditScheme(ctx, fullURL)
+        if strings.HasPrefix(fullURL, "https://") {
+            report.HTTPS = outcome
+            if resp != nil {
+                parseHSTS(resp.Header.Get("Strict-Transport-Security"), report)
+            }
+        } else {
+            // A 400 from a TLS port is not plain HTTP being served
+            if outcome.Status == http.StatusBadRequest && ac.isHTTPOnTLSResponse(ctx, fullURL) {
+                outcome.Status = 0
+                outcome.Error = MismatchHTTPOnTLS
+            }
+            report.HTTP = outcome
+            report.UpgradesToHTTPS = isHTTPSUpgrade(fullURL, outcome.Redirect)
+        }
+    }
+    return report
+}
+
+// auditScheme makes one HEAD request that never follows redirects
+func (ac *AliveHTTPClient) auditScheme(ctx context.Context, fullURL string) (*SchemeOutcome, *http.Response) {
+    outcome := &SchemeOutcome{URL: fullURL}
+
+    req, err := ac.createRequest(ctx, "HEAD", fullURL, RequestTypeCheck)
+    if err != nil {
+        outcome.Error = err.Error()
+        return outcome, nil
+    }
+
+    resp, err := ac.noRedirectClient.Do(req)
+    if err != nil {
+        outcome.Error = err.Error()
+        return outcome, nil
+    }
+    resp.Body.Close()
+
+    outcome.Status = resp.StatusCode
+    if isRedirect(resp.StatusCode) {
+        outcome.Redirect = resp.Header.Get("Location")
+    }
+    return outcome, resp
+}
+
+// isHTTPSUpgrade reports whether an HTTP redirect points at HTTPS on the same host
+func isHTTPSUpgrade(fromURL, location string) bool {
+    if location == "" {
+        return false
+    }
+    from, err := url.Parse(fromURL)
+    if err != nil {
+        return false
+    }
+    to, err := from.Parse(location)
+    if err != nil {
+        return false
+    }
+    return to.Scheme == "https" && strings.EqualFold(to.Hostname(), from.Hostname())
+}
+
+// parseHSTS fills the HSTS fields from a Strict-Transport-Security header
+func parseHSTS(header string, report *SchemeReport) {
+    if header == "" {
+        return
+    }
+    report.HSTS = true
+
+    for _, directive := range strings.Split(header, ";") {
+        directive = strings.TrimSpace(directive)
+        name, value, _ := strings.Cut(directive, "=")
+        switch strings.ToLower(strings.TrimSpace(name)) {
+        case "max-age":
+            if maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(value), `"`), 10, 64); err == nil {
+                report.HSTSMaxAge = maxAge
+            }
+        case "includesubdomains":
+            report.HSTSIncludeSubdomains = true
+        case "preload":
+            report.HSTSPreload = true
+        }
+    }
+
+    // max-age=0 tells browsers to forget the policy
+    if report.HSTSMaxAge == 0 {
+        report.HSTS = false
+    }
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+    "bytes"
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+const (
+    MAX_CIDR_HOSTS = 65536 // Largest CIDR range expanded from a scope file (/16)
+)
+
+// Scope asset kinds we know how to probe
+const (
+    ScopeAssetURL      = "url"
+    ScopeAssetWildcard = "wildcard"
+    ScopeAssetCIDR     = "cidr"
+    ScopeAssetOther    = "other"
+)
+
+// ScopeAsset is a single normalized entry from a bug bounty platform scope export
+type ScopeAsset struct {
+    Identifier string
+    Kind       string
+    InScope    bool
+    Bounty     bool
+}
+
+// scopeRecord is a raw scope row with lowercased field names
+type scopeRecord map[string]string
+
+// Field aliases used by HackerOne, Bugcrowd and Intigriti exports
+var (
+    scopeIdentifierFields = []string{"identifier", "asset_identifier", "target", "endpoint", "uri", "asset", "name"}
+    scopeTypeFields       = []string{"asset_type", "type", "category"}
+    scopeBountyFields     = []string{"eligible_for_bounty", "bounty"}
+    scopeInScopeFields    = []string{"eligible_for_submission", "in_scope"}
+    scopeTierFields       = []string{"tier"}
+)
+
+// field returns the first non-empty value among the given aliases
+func (r scopeRecord) field(aliases []string) string {
+    for _, alias := range aliases {
+        if value := strings.TrimSpace(r[alias]); value != "" {
+            return value
+        }
+    }
+    return ""
+}
+
+// readScopeInput reads a platform scope export and returns the in-scope web targets
+func readScopeInput(filename string, bountyOnly bool) ([]Target, error) {
+    data, err := os.ReadFile(filename)
+    if err != nil {
+        return nil, fmt.Errorf("error opening scope file %s: %v", filename, err)
+    }
+    fmt.Fprintf(os.Stderr, "Loading scope %s (%d bytes)...\n", filename, len(data))
+
+    assets, err := parseScopeExport(data, filepath.Ext(filename))
+    if err != nil {
+        return nil, fmt.Errorf("error parsing scope file %s: %v", filename, err)
+    }
+
+    targets, summary := scopeTargets(assets, bountyOnly)
+    fmt.Fprintf(os.Stderr, "Scope: %s\n", summary)
+
+    if len(targets) == 0 {
+        return nil, fmt.Errorf("no in-scope web assets found in scope file %s", filename)
+    }
+    return newTargets(targets), nil
+}
+
+// parseScopeExport detects CSV or JSON and converts every row into a ScopeAsset
+func parseScopeExport(data []byte, ext string) ([]ScopeAsset, error) {
+    var records []scopeRecord
+    var err error
+
+    trimmed := bytes.TrimSpace(data)
+    if strings.EqualFold(ext, ".json") || (len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')) {
+        records, err = parseScopeJSON(trimmed)
+    } else {
+        records, err = parseScopeCSV(trimmed)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var assets []ScopeAsset
+    for _, record := range records {
+        assets = append(assets, scopeAssetsFromRecord(record)...)
+    }
+    return assets, nil
+}
+
+// parseScopeCSV reads a header-based CSV export
+func parseScopeCSV(data []byte) ([]scopeRecord, error) {
+    reader := csv.NewReader(bytes.NewReader(data))
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+
+    header, err := reader.Read()
+    if err != nil {
+        return nil, fmt.Errorf("missing CSV header: %v", err)
+    }
+    for i := range header {
+        header[i] = normalizeScopeKey(header[i])
+    }
+
+    var records []scopeRecord
+    for {
+        row, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        record := scopeRecord{}
+        for i, value := range row {
+            if i < len(header) {
+                record[header[i]] = value
+            }
+        }
+        records = append(records, record)
+    }
+    return records, nil
+}
+
+// parseScopeJSON walks the known JSON export layouts and collects scope rows
+func parseScopeJSON(data []byte) ([]scopeRecord, error) {
+    var root interface{}
+    if err := json.Unmarshal(data, &root); err != nil {
+        return nil, err
+    }
+
+    var records []scopeRecord
+    collectScopeRecords(root, "", &records)
+    if len(records) == 0 {
+        return nil, errors.New("no scope entries found in JSON export")
+    }
+    return records, nil
+}
+
+// collectScopeRecords recursively finds scope entries; inScope is forced by
+// enclosing "in_scope"/"out_of_scope" arrays as used by Bugcrowd and Intigriti
+func collectScopeRecords(node interface{}, inScope string, records *[]scopeRecord) {
+    switch v := node.(type) {
+    case []interface{}:
+        for _, item := range v {
+            collectScopeRecords(item, inScope, records)
+        }
+    case map[string]interface{}:
+        // HackerOne API wraps fields in "attributes"
+        if attrs, ok := v["attributes"].(map[string]interface{}); ok {
+            collectScopeRecords(attrs, inScope, records)
+            return
+        }
+
+        record := scopeRecord{}
+        for key, value := range v {
+            if s, ok := scopeValueString(value); ok {
+                record[normalizeScopeKey(key)] = s
+            }
+        }
+        if record["asset_identifier"] != "" || (record.field(scopeIdentifierFields) != "" && record.field(scopeTypeFields) != "") {
+            if inScope != "" {
+                record["in_scope"] = inScope
+            }
+            *records = append(*records, record)
+            return
+        }
+
+        // Not a scope entry itself, descend into children
+        for key, value := range v {
+            switch normalizeScopeKey(key) {
+            case "in_scope":
+                collectScopeRecords(value, "true", records)
+            case "out_of_scope":
+                collectScopeRecords(value, "false", records)
+            default:
+                collectScopeRecords(value, inScope, records)
+            }
+        }
+    }
+}
+
+// scopeValueString flattens scalar JSON values and Intigriti {"value": ...} objects
+func scopeValueString(value interface{}) (string, bool) {
+    switch v := value.(type) {
+    case string:
+        return v, true
+    case bool:
+        return fmt.Sprintf("%t", v), true
+    case float64:
+        return fmt.Sprintf("%g", v), true
+    case map[string]interface{}:
+        if inner, ok := v["value"]; ok {
+            return scopeValueString(inner)
+        }
+    }
+    return "", false
+}
+
+// normalizeScopeKey lowercases a header or JSON key and unifies separators
+func normalizeScopeKey(key string) string {
+    key = strings.ToLower(strings.TrimSpace(key))
+    key = strings.TrimPrefix(key, "\ufeff")
+    key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+    if key == "inscope" {
+        key = "in_scope"
+    }
+    return key
+}
+
+// scopeAssetsFromRecord classifies a raw row, splitting comma-separated identifiers
+func scopeAssetsFromRecord(record scopeRecord) []ScopeAsset {
+    identifier := record.field(scopeIdentifierFields)
+    if identifier == "" {
+        return nil
+    }
+
+    inScope := parseScopeBool(record.field(scopeInScopeFields), true)
+    bounty := parseScopeBool(record.field(scopeBountyFields), true)
+
+    // Intigriti encodes scope and bounty eligibility in the tier
+    switch tier := strings.ToLower(record.field(scopeTierFields)); {
+    case strings.Contains(tier, "out of scope"):
+        inScope = false
+    case strings.Contains(tier, "no bounty"):
+        bounty = false
+    }
+
+    var assets []ScopeAsset
+    for _, id := range strings.Split(identifier, ",") {
+        id = strings.TrimSpace(id)
+        if id == "" {
+            continue
+        }
+        assets = append(assets, ScopeAsset{
+            Identifier: id,
+            Kind:       classifyScopeAsset(record.field(scopeTypeFields), id),
+            InScope:    inScope,
+            Bounty:     bounty,
+        })
+    }
+    return assets
+}
+
+// parseScopeBool understands the boolean spellings found in exports
+func parseScopeBool(value string, fallback bool) bool {
+    switch strings.ToLower(strings.TrimSpace(value)) {
+    case "true", "yes", "1", "in scope", "in_scope":
+        return true
+    case "false", "no", "0", "out of scope", "out_of_scope":
+        return false
+    }
+    return fallback
+}
+
+// classifyScopeAsset maps a platform asset type to one of the probeable kinds
+func classifyScopeAsset(assetType, identifier string) string {
+    t := strings.ToLower(assetType)
+    switch {
+    case strings.Contains(t, "wildcard"):
+        return ScopeAssetWildcard
+    case strings.Contains(t, "cidr"), strings.Contains(t, "iprange"), strings.Contains(t, "ip_range"),
+        strings.Contains(t, "ip_address"), strings.Contains(t, "network"):
+        return ScopeAssetCIDR
+    case t == "url", t == "domain", t == "website", t == "api", t == "web", strings.Contains(t, "url"):
+        if strings.HasPrefix(identifier, "*.") {
+            return ScopeAssetWildcard
+        }
+        return ScopeAssetURL
+    case t != "":
+        return ScopeAssetOther
+    }
+
+    // No type column, infer from the identifier itself
+    switch {
+    case strings.HasPrefix(identifier, "*."):
+        return ScopeAssetWildcard
+    case net.ParseIP(identifier) != nil:
+        return ScopeAssetCIDR
+    }
+    if _, _, err := net.ParseCIDR(identifier); err == nil {
+        return ScopeAssetCIDR
+    }
+    if isValidURL(identifier) {
+        return ScopeAssetURL
+    }
+    return ScopeAssetOther
+}
+
+// scopeTargets turns assets into probe targets, dropping anything out of scope
+func scopeTargets(assets []ScopeAsset, bountyOnly bool) ([]string, string) {
+    exclusions := newScopeExclusions(assets)
+
+    var targets []string
+    seen := make(map[string]bool)
+    var inScope, outOfScope, noBounty, skipped int
+
+    add := func(target string) {
+        if target == "" || seen[target] || exclusions.excludes(target) {
+            return
+        }
+        seen[target] = true
+        targets = append(targets, target)
+    }
+
+    for _, asset := range assets {
+        if !asset.InScope {
+            outOfScope++
+            continue
+        }
+        if bountyOnly && !asset.Bounty {
+            noBounty++
+            continue
+        }
+
+        switch asset.Kind {
+        case ScopeAssetURL:
+            add(asset.Identifier)
+        case ScopeAssetWildcard:
+            // Subdomains are unknown here, probe the apex of the wildcard
+            apex := strings.Replace(asset.Identifier, "*.", "", 1)
+            if strings.Contains(apex, "*") {
+                skipped++
+                continue
+            }
+            add(apex)
+        case ScopeAssetCIDR:
+            hosts, err := expandCIDR(asset.Identifier)
+            if err != nil {
+                fmt.Fprintf(os.Stderr, "Skipping scope asset %s: %v\n", asset.Identifier, err)
+                skipped++
+                continue
+            }
+            for _, host := range hosts {
+                add(host)
+            }
+        default:
+            skipped++
+            continue
+        }
+        inScope++
+    }
+
+    summary := fmt.Sprintf("%d in-scope web assets, %d out of scope, %d skipped (non-web)", inScope, outOfScope, skipped)
+    if bountyOnly {
+        summary += fmt.Sprintf(", %d not eligible for bounty", noBounty)
+    }
+    summary += fmt.Sprintf(" -> %d targets", len(targets))
+    return targets, summary
+}
+
+// stripScheme removes an http:// or https:// prefix
+func stripScheme(target string) string {
+    return strings.TrimPrefix(strings.TrimPrefix(target, "https://"), "http://")
+}
+
+// expandCIDR lists the host addresses of a CIDR range or a single IP
+func expandCIDR(cidr string) ([]string, error) {
+    if ip := net.ParseIP(cidr); ip != nil {
+        return []string{ip.String()}, nil
+    }
+
+    ip, network, err := net.ParseCIDR(cidr)
+    if err != nil {
+        return nil, err
+    }
+
+    ones, bits := network.Mask.Size()
+    if bits-ones > 16 {
+        return nil, fmt.Errorf("range larger than %d addresses", MAX_CIDR_HOSTS)
+    }
+
+    var hosts []string
+    for current := ip.Mask(network.Mask); network.Contains(current); current = nextIP(current) {
+        hosts = append(hosts, current.String())
+    }
+
+    // Drop network and broadcast addresses for IPv4 ranges that have them
+    if ip.To4() != nil && bits-ones >= 2 && len(hosts) > 2 {
+        hosts = hosts[1 : len(hosts)-1]
+    }
+    return hosts, nil
+}
+
+// nextIP returns the address following ip
+func nextIP(ip net.IP) net.IP {
+    next := make(net.IP, len(ip))
+    copy(next, ip)
+    for i := len(next) - 1; i >= 0; i-- {
+        next[i]++
+        if next[i] != 0 {
+            break
+        }
+    }
+    return next
+}
+
+// scopeExclusions holds out-of-scope hosts, wildcard suffixes and networks
+type scopeExclusions struct {
+    hosts    map[string]bool
+    suffixes []string
+    networks []*net.IPNet
+}
+
+// newScopeExclusions builds the exclusion set from out-of-scope assets
+func newScopeExclusions(assets []ScopeAsset) *scopeExclusions {
+    ex := &scopeExclusions{hosts: make(map[string]bool)}
+    for _, asset := range assets {
+        if asset.InScope {
+            continue
+        }
+
+        id := strings.ToLower(stripScheme(asset.Identifier))
+        switch {
+        case strings.HasPrefix(id, "*."):
+            ex.suffixes = append(ex.suffixes, id[1:])
+        case strings.Contains(id, "/") && asset.Kind == ScopeAssetCIDR:
+            if _, network, err := net.ParseCIDR(id); err == nil {
+                ex.networks = append(ex.networks, network)
+            }
+        default:
+            ex.hosts[strings.TrimSuffix(id, "/")] = true
+        }
+    }
+    return ex
+}
+
+// excludes reports whether a target falls under an out-of-scope entry
+func (ex *scopeExclusions) excludes(target string) bool {
+    target = strings.ToLower(stripScheme(target))
+    if ex.hosts[strings.TrimSuffix(target, "/")] {
+        return true
+    }
+
+    host := target
+    if idx := strings.IndexAny(host, "/?#"); idx != -1 {
+        host = host[:idx]
+    }
+    if h, _, err := net.SplitHostPort(host); err == nil {
+        host = h
+    }
+    if ex.hosts[host] {
+        return true
+    }
+
+    for _, suffix := range ex.suffixes {
+        if strings.HasSuffix(host, suffix) {
+            return true
+        }
+    }
+
+    if ip := net.ParseIP(host); ip != nil {
+        for _, network := range ex.networks {
+            if network.Contains(ip) {
+                return true
+            }
+        }
+    }
+    return false
+}
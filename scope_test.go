@@ -0,0 +1,124 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestScopeTargets(t *testing.T) {
+    tests := []struct {
+        file       string
+        bountyOnly bool
+        want       []string
+    }{
+        {
+            file: "hackerone.csv",
+            // 192.0.2.5 is listed out of scope inside the in-scope /29
+            want: []string{"www.example.com", "example.com", "api.example.com", "graphql.example.com",
+                "192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4", "192.0.2.6"},
+        },
+        {
+            file:       "hackerone.csv",
+            bountyOnly: true,
+            want:       []string{"www.example.com", "example.com", "api.example.com", "graphql.example.com"},
+        },
+        {
+            file: "hackerone.json",
+            want: []string{"https://shop.example.com", "example.net"},
+        },
+        {
+            file:       "hackerone.json",
+            bountyOnly: true,
+            want:       []string{"https://shop.example.com"},
+        },
+        {
+            file: "bugcrowd.json",
+            want: []string{"https://app.example.org", "api.example.org", "example.org"},
+        },
+        {
+            file: "intigriti.csv",
+            want: []string{"portal.example.io", "example.io", "198.51.100.1", "198.51.100.2"},
+        },
+        {
+            file:       "intigriti.csv",
+            bountyOnly: true,
+            want:       []string{"portal.example.io", "example.io"},
+        },
+    }
+
+    for _, tt := range tests {
+        path := filepath.Join("testdata", "scopes", tt.file)
+        data, err := os.ReadFile(path)
+        if err != nil {
+            t.Fatal(err)
+        }
+        assets, err := parseScopeExport(data, filepath.Ext(path))
+        if err != nil {
+            t.Fatalf("%s: %v", tt.file, err)
+        }
+        got, _ := scopeTargets(assets, tt.bountyOnly)
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s (bounty only %t): got %v, want %v", tt.file, tt.bountyOnly, got, tt.want)
+        }
+    }
+}
+
+func TestScopeExclusions(t *testing.T) {
+    assets := []ScopeAsset{
+        {Identifier: "*.internal.example.com", Kind: ScopeAssetWildcard},
+        {Identifier: "https://legacy.example.com/", Kind: ScopeAssetURL},
+        {Identifier: "10.0.0.0/24", Kind: ScopeAssetCIDR},
+        {Identifier: "*.example.com", Kind: ScopeAssetWildcard, InScope: true},
+    }
+    ex := newScopeExclusions(assets)
+
+    tests := []struct {
+        target string
+        want   bool
+    }{
+        {"vpn.internal.example.com", true},
+        {"https://db.internal.example.com:8443/login", true},
+        {"legacy.example.com", true},
+        {"http://legacy.example.com/path", true},
+        {"10.0.0.7", true},
+        {"10.0.1.7", false},
+        {"www.example.com", false},
+        {"internal.example.com", false},
+    }
+    for _, tt := range tests {
+        if got := ex.excludes(tt.target); got != tt.want {
+            t.Errorf("excludes(%q) = %t, want %t", tt.target, got, tt.want)
+        }
+    }
+}
+
+func TestExpandCIDR(t *testing.T) {
+    tests := []struct {
+        cidr    string
+        want    []string
+        wantErr bool
+    }{
+        {cidr: "192.0.2.7", want: []string{"192.0.2.7"}},
+        {cidr: "192.0.2.0/30", want: []string{"192.0.2.1", "192.0.2.2"}},
+        {cidr: "192.0.2.0/31", want: []string{"192.0.2.0", "192.0.2.1"}},
+        {cidr: "192.0.2.9/32", want: []string{"192.0.2.9"}},
+        {cidr: "2001:db8::/127", want: []string{"2001:db8::", "2001:db8::1"}},
+        {cidr: "10.0.0.0/15", wantErr: true},
+        {cidr: "not-a-range", wantErr: true},
+    }
+    for _, tt := range tests {
+        got, err := expandCIDR(tt.cidr)
+        if (err != nil) != tt.wantErr {
+            t.Errorf("expandCIDR(%q) error = %v, want error %t", tt.cidr, err, tt.wantErr)
+            continue
+        }
+        if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("expandCIDR(%q) = %v, want %v", tt.cidr, got, tt.want)
+        }
+    }
+    if hosts, err := expandCIDR("10.0.0.0/16"); err != nil || len(hosts) != MAX_CIDR_HOSTS-2 {
+        t.Errorf("expandCIDR(/16) = %d hosts, %v; want %d", len(hosts), err, MAX_CIDR_HOSTS-2)
+    }
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/subtle"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "flag"
+    "fmt"
+    "net"
+    "net/http"
+    "os"
+    "os/signal"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "syscall"
+    "time"
+)
+
+const (
+    DEFAULT_LISTEN_ADDR = "127.0.0.1:8080"
+    DEFAULT_MAX_JOBS    = 2                // Jobs scanning at the same time
+    DEFAULT_MAX_QUEUED  = 100              // Jobs waiting for a free slot
+    MAX_FINISHED_JOBS   = 100              // Finished jobs kept for polling
+    MAX_REQUEST_BODY    = 32 * 1024 * 1024 // Largest accepted job submission
+)
+
+// Job states
+const (
+    JobQueued    = "queued"
+    JobRunning   = "running"
+    JobDone      = "done"
+    JobCancelled = "cancelled"
+)
+
+// JobConfig is the per-job scan configuration accepted by the API
+type JobConfig struct {
+    Workers         int     `json:"workers,omitempty"`
+    Rate            float64 `json:"rate,omitempty"`
+    Timeout         string  `json:"timeout,omitempty"` // Go duration, e.g. "5s"
+    Fast            bool    `json:"fast,omitempty"`
+    Verify          bool    `json:"verify,omitempty"`
+    Title           bool    `json:"title,omitempty"`
+    RobustTitle     bool    `json:"robust_title,omitempty"`
+    FollowRedirects bool    `json:"follow_redirects,omitempty"`
+    ShowFailed      bool    `json:"show_failed,omitempty"`
+    StatusCodes     []int   `json:"status_codes,omitempty"`
+    Probe           string  `json:"probe,omitempty"`
+    SchemeAudit     bool    `json:"scheme_audit,omitempty"`
+    HTTP2           bool    `json:"http2,omitempty"`
+    HTTP3           bool    `json:"http3,omitempty"`
+    H2C             bool    `json:"h2c,omitempty"`
+    Banner          bool    `json:"banner,omitempty"`
+    TLSMin          string  `json:"tls_min,omitempty"`
+    Headers         []string        `json:"headers,omitempty"`        // Header names to capture, or ["all"]
+    Match           ResponseMatchers `json:"match,omitempty"`  // Same as the -m* flags
+    Filter          ResponseMatchers `json:"filter,omitempty"` // Same as the -f* flags
+}
+
+// JobRequest is the body of POST /jobs
+type JobRequest struct {
+    Targets []string  `json:"targets"`
+    Config  JobConfig `json:"config"`
+}
+
+// JobStatus is returned when submitting and polling jobs
+type JobStatus struct {
+    ID         string     `json:"id"`
+    State      string     `json:"state"`
+    CreatedAt  time.Time  `json:"created_at"`
+    StartedAt  *time.Time `json:"started_at,omitempty"`
+    FinishedAt *time.Time `json:"finished_at,omitempty"`
+    Targets    int64      `json:"targets"`
+    Checked    uint64     `json:"checked"`
+    Alive      uint64     `json:"alive"`
+    Verified   uint64     `json:"verified"`
+    Errors     uint64     `json:"errors"`
+    Results    int        `json:"results"`
+}
+
+// Job is one submitted scan
+type Job struct {
+    id      string
+    config  *Config
+    targets []Target
+    stats   *Stats
+    cancel  context.CancelFunc
+
+    mu       sync.Mutex
+    state    string
+    created  time.Time
+    started  time.Time
+    finished time.Time
+    results  []*Result
+    changed  chan struct{} // Closed and replaced whenever results or state change
+}
+
+// Server runs scan jobs submitted over HTTP
+type Server struct {
+    base       *Config
+    token      string
+    maxWorkers int
+    slots      chan struct{} // Bounds jobs scanning at the same time
+    pending    chan struct{} // Bounds jobs queued or scanning
+
+    mu    sync.Mutex
+    jobs  map[string]*Job
+    order []string // Job IDs in submission order, for listing and eviction
+}
+
+// runServe parses the serve flags and runs the API until interrupted
+func runServe(args []string) {
+    fs := flag.NewFlagSet("serve", flag.ExitOnError)
+    listen := fs.String("listen", DEFAULT_LISTEN_ADDR, "Address to listen on")
+    token := fs.String("token", os.Getenv("ALIVEHUNTER_API_TOKEN"), "API token required in 'Authorization: Bearer' (default: $ALIVEHUNTER_API_TOKEN)")
+    maxJobs := fs.Int("max-jobs", DEFAULT_MAX_JOBS, "Jobs scanning at the same time, others wait in a queue")
+    maxQueued := fs.Int("max-queued", DEFAULT_MAX_QUEUED, "Jobs waiting in the queue before submissions are rejected")
+    maxWorkers := fs.Int("max-workers", DEFAULT_WORKERS, "Maximum workers per job")
+    fs.Parse(args)
+
+    server := NewServer(*token, *maxJobs, *maxQueued, *maxWorkers)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    httpServer := &http.Server{
+        Addr:              *listen,
+        Handler:           server.Handler(ctx),
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+
+    sigChan := make(chan os.Signal, 1)
+    signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+    go func() {
+        <-sigChan
+        fmt.Fprintf(os.Stderr, "\nReceived interrupt, cancelling jobs and shutting down...\n")
+        cancel()
+        shutdownCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+        defer done()
+        httpServer.Shutdown(shutdownCtx)
+    }()
+
+    if server.token == "" {
+        if host, _, err := net.SplitHostPort(*listen); err == nil && !isLoopbackHost(host) {
+            fmt.Fprintf(os.Stderr, "Warning: no -token set and listening on %s, anyone who can reach it can run scans\n", *listen)
+        }
+    }
+    fmt.Fprintf(os.Stderr, "AliveHunter v%s API listening on %s (max %d concurrent jobs)\n", VERSION, *listen, cap(server.slots))
+
+    if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+        fmt.Fprintf(os.Stderr, "Error starting server: %v\n", err)
+        os.Exit(1)
+    }
+}
+
+// NewServer creates an API server running up to maxJobs jobs at a time with
+// up to maxQueued more waiting
+func NewServer(token string, maxJobs, maxQueued, maxWorkers int) *Server {
+    if maxJobs < 1 {
+        maxJobs = 1
+    }
+    if maxQueued < 0 {
+        maxQueued = 0
+    }
+    return &Server{
+        base:       defaultConfig(),
+        token:      token,
+        maxWorkers: maxWorkers,
+        slots:      make(chan struct{}, maxJobs),
+        pending:    make(chan struct{}, maxJobs+maxQueued),
+        jobs:       make(map[string]*Job),
+    }
+}
+
+// isLoopbackHost reports whether a listen host only accepts local connections
+func isLoopbackHost(host string) bool {
+    if host == "localhost" {
+        return true
+    }
+    ip := net.ParseIP(host)
+    return ip != nil && ip.IsLoopback()
+}
+
+// Handler returns the API routes; jobs are cancelled when ctx is done
+func (s *Server) Handler(ctx context.Context) http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc("POST /jobs", func(w http.ResponseWriter, r *http.Request) { s.handleSubmit(ctx, w, r) })
+    mux.HandleFunc("GET /jobs", s.handleList)
+    mux.HandleFunc("GET /jobs/{id}", s.handleStatus)
+    mux.HandleFunc("GET /jobs/{id}/results", s.handleResults)
+    mux.HandleFunc("DELETE /jobs/{id}", s.handleCancel)
+    return requireToken(s.token, mux)
+}
+
+// requireToken rejects requests without the bearer token when one is configured
+func requireToken(token string, next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if token != "" {
+            given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+            if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+                writeError(w, http.StatusUnauthorized, "invalid or missing API token")
+                return
+            }
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// handleSubmit creates a job and queues it for scanning
+func (s *Server) handleSubmit(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+    var req JobRequest
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MAX_REQUEST_BODY)).Decode(&req); err != nil {
+        writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid job request: %v", err))
+        return
+    }
+
+    config, err := s.jobConfig(req.Config)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    targets, _ := normalizeTargets(newTargets(req.Targets))
+    if len(targets) == 0 {
+        writeError(w, http.StatusBadRequest, "no valid targets")
+        return
+    }
+
+    // Queued jobs hold their targets and a goroutine, so their number is capped
+    select {
+    case s.pending <- struct{}{}:
+    default:
+        w.Header().Set("Retry-After", "60")
+        writeError(w, http.StatusTooManyRequests, fmt.Sprintf("job queue full (%d jobs queued or running)", cap(s.pending)))
+        return
+    }
+
+    job := &Job{
+        id:      newJobID(),
+        config:  config,
+        targets: targets,
+        stats:   &Stats{totalUrls: int64(len(targets))},
+        state:   JobQueued,
+        created: time.Now(),
+        changed: make(chan struct{}),
+    }
+    jobCtx, cancel := context.WithCancel(ctx)
+    job.cancel = cancel
+
+    s.mu.Lock()
+    s.jobs[job.id] = job
+    s.order = append(s.order, job.id)
+    s.evictFinished()
+    s.mu.Unlock()
+
+    go s.run(jobCtx, job)
+
+    w.Header().Set("Location", "/jobs/"+job.id)
+    writeJSON(w, http.StatusAccepted, job.Status())
+}
+
+// jobConfig builds a scan configuration from the server defaults and the request
+func (s *Server) jobConfig(jc JobConfig) (*Config, error) {
+    config := *s.base
+    config.Silent = true // No progress output from the server process
+    config.OnlyStatus = jc.StatusCodes
+    config.FastMode = jc.Fast
+    config.VerifyMode = jc.Verify
+    config.ExtractTitle = jc.Title
+    config.RobustTitle = jc.RobustTitle
+    config.FollowRedirect = jc.FollowRedirects
+    config.ShowFailed = jc.ShowFailed
+    config.SchemeAudit = jc.SchemeAudit
+    config.HTTP2 = jc.HTTP2
+    config.HTTP3 = jc.HTTP3
+    config.H2C = jc.H2C
+    config.BannerGrab = jc.Banner
+    config.Headers = jc.Headers
+    config.Match = jc.Match
+    config.Filter = jc.Filter
+    config.TLSMinVersion = parseTLSVersion(jc.TLSMin)
+
+    // Built-in -fast/-verify tuning; explicit job values below override it
+    if jc.Fast {
+        if err := applyProfile(&config, ProfileFast); err != nil {
+            return nil, fmt.Errorf("%s profile: %v", ProfileFast, err)
+        }
+    }
+    if jc.Verify {
+        if err := applyProfile(&config, ProfileVerify); err != nil {
+            return nil, fmt.Errorf("%s profile: %v", ProfileVerify, err)
+        }
+    }
+
+    if jc.Workers > 0 {
+        config.Workers = jc.Workers
+    }
+    if jc.Rate > 0 {
+        config.Rate = jc.Rate
+    }
+    if jc.Timeout != "" {
+        timeout, err := time.ParseDuration(jc.Timeout)
+        if err != nil || timeout <= 0 {
+            return nil, fmt.Errorf("invalid timeout %q", jc.Timeout)
+        }
+        config.Timeout = timeout
+    }
+    if jc.Probe != "" {
+        if err := validateProbeStrategy(jc.Probe); err != nil {
+            return nil, fmt.Errorf("invalid probe: %v", err)
+        }
+        config.ProbeStrategy = jc.Probe
+    }
+
+    applyOperationMode(&config)
+    if config.Workers > s.maxWorkers {
+        config.Workers = s.maxWorkers
+    }
+    return &config, nil
+}
+
+// run waits for a free slot and scans the job's targets
+func (s *Server) run(ctx context.Context, job *Job) {
+    defer func() { <-s.pending }()
+    defer job.cancel()
+
+    select {
+    case s.slots <- struct{}{}:
+        defer func() { <-s.slots }()
+    case <-ctx.Done():
+        job.finish(JobCancelled)
+        return
+    }
+
+    job.start()
+    client := NewAliveHTTPClient(job.config)
+    defer client.Close()
+    runScan(ctx, job.targets, client, job.config, job.stats, func(result *Result) {
+        if shouldOutput(result, job.config) {
+            job.add(result)
+        }
+    })
+
+    if ctx.Err() != nil {
+        job.finish(JobCancelled)
+    } else {
+        job.finish(JobDone)
+    }
+}
+
+// evictFinished drops the oldest finished jobs beyond MAX_FINISHED_JOBS;
+// callers hold s.mu
+func (s *Server) evictFinished() {
+    finished := 0
+    for _, id := range s.order {
+        if s.jobs[id].Finished() {
+            finished++
+        }
+    }
+
+    kept := s.order[:0]
+    for _, id := range s.order {
+        if finished > MAX_FINISHED_JOBS && s.jobs[id].Finished() {
+            delete(s.jobs, id)
+            finished--
+            continue
+        }
+        kept = append(kept, id)
+    }
+    s.order = kept
+}
+
+// lookup finds the job named in the request path
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) *Job {
+    s.mu.Lock()
+    job := s.jobs[r.PathValue("id")]
+    s.mu.Unlock()
+    if job == nil {
+        writeError(w, http.StatusNotFound, "job not found")
+    }
+    return job
+}
+
+// handleList returns the status of every job
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    statuses := make([]JobStatus, 0, len(s.order))
+    for _, id := range s.order {
+        statuses = append(statuses, s.jobs[id].Status())
+    }
+    s.mu.Unlock()
+    writeJSON(w, http.StatusOK, statuses)
+}
+
+// handleStatus returns one job's status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+    if job := s.lookup(w, r); job != nil {
+        writeJSON(w, http.StatusOK, job.Status())
+    }
+}
+
+// handleCancel stops a queued or running job
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+    if job := s.lookup(w, r); job != nil {
+        job.cancel()
+        writeJSON(w, http.StatusAccepted, job.Status())
+    }
+}
+
+// handleResults streams results as NDJSON, or as Server-Sent Events when the
+// client accepts text/event-stream; the stream ends when the job finishes
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+    job := s.lookup(w, r)
+    if job == nil {
+        return
+    }
+
+    sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+    if sse {
+        w.Header().Set("Content-Type", "text/event-stream")
+        w.Header().Set("Cache-Control", "no-cache")
+    } else {
+        w.Header().Set("Content-Type", "application/x-ndjson")
+    }
+    flusher, _ := w.(http.Flusher)
+
+    sent := 0
+    for {
+        results, finished, changed := job.since(sent)
+        for _, result := range results {
+            data, _ := json.Marshal(result)
+            if sse {
+                fmt.Fprintf(w, "event: result\ndata: %s\n\n", data)
+            } else {
+                fmt.Fprintf(w, "%s\n", data)
+            }
+        }
+        sent += len(results)
+
+        if finished && sse {
+            data, _ := json.Marshal(job.Status())
+            fmt.Fprintf(w, "event: done\ndata: %s\n\n", data)
+        }
+        if flusher != nil {
+            flusher.Flush()
+        }
+        if finished {
+            return
+        }
+
+        select {
+        case <-changed:
+        case <-r.Context().Done():
+            return
+        }
+    }
+}
+
+// start marks the job as running
+func (j *Job) start() {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.state = JobRunning
+    j.started = time.Now()
+    j.stats.started = j.started
+    j.notify()
+}
+
+// add appends a result for streaming
+func (j *Job) add(result *Result) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.results = append(j.results, result)
+    j.notify()
+}
+
+// finish records the final state
+func (j *Job) finish(state string) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.state = state
+    j.finished = time.Now()
+    j.notify()
+}
+
+// notify wakes up streams waiting for changes; callers hold j.mu
+func (j *Job) notify() {
+    close(j.changed)
+    j.changed = make(chan struct{})
+}
+
+// since returns results after the first n, whether the job has finished and
+// a channel closed on the next change
+func (j *Job) since(n int) ([]*Result, bool, <-chan struct{}) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    return j.results[n:], j.state == JobDone || j.state == JobCancelled, j.changed
+}
+
+// Finished reports whether the job is done or cancelled
+func (j *Job) Finished() bool {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    return j.state == JobDone || j.state == JobCancelled
+}
+
+// Status returns a snapshot of the job and its Stats
+func (j *Job) Status() JobStatus {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+
+    status := JobStatus{
+        ID:        j.id,
+        State:     j.state,
+        CreatedAt: j.created,
+        Targets:   j.stats.totalUrls,
+        Checked:   atomic.LoadUint64(&j.stats.checked),
+        Alive:     atomic.LoadUint64(&j.stats.alive),
+        Verified:  atomic.LoadUint64(&j.stats.verified),
+        Errors:    atomic.LoadUint64(&j.stats.errors),
+        Results:   len(j.results),
+    }
+    if !j.started.IsZero() {
+        started := j.started
+        status.StartedAt = &started
+    }
+    if !j.finished.IsZero() {
+        finished := j.finished
+        status.FinishedAt = &finished
+    }
+    return status
+}
+
+// newJobID returns a random job identifier
+func newJobID() string {
+    b := make([]byte, 8)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+    writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+// submitJob posts a job to the API and decodes the returned status
+func submitJob(t *testing.T, api string, body string) (int, JobStatus) {
+    t.Helper()
+    resp, err := http.Post(api+"/jobs", "application/json", bytes.NewBufferString(body))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    var status JobStatus
+    json.NewDecoder(resp.Body).Decode(&status)
+    return resp.StatusCode, status
+}
+
+func TestServerQueueLimit(t *testing.T) {
+    // The target holds every request until the test ends
+    release := make(chan struct{})
+    target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case <-release:
+        case <-r.Context().Done():
+        }
+    }))
+    defer target.Close()
+    defer close(release)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    api := httptest.NewServer(NewServer("", 1, 1, 10).Handler(ctx))
+    defer api.Close()
+
+    job := fmt.Sprintf(`{"targets": [%q], "config": {"timeout": "30s", "probe": "http"}}`, target.URL)
+
+    // One running and one queued fill the server
+    code, _ := submitJob(t, api.URL, job)
+    if code != http.StatusAccepted {
+        t.Fatalf("first job: status %d", code)
+    }
+    code, queued := submitJob(t, api.URL, job)
+    if code != http.StatusAccepted {
+        t.Fatalf("second job: status %d", code)
+    }
+    if code, _ := submitJob(t, api.URL, job); code != http.StatusTooManyRequests {
+        t.Fatalf("third job: status %d, want %d", code, http.StatusTooManyRequests)
+    }
+
+    // Cancelling the queued job frees its place
+    req, _ := http.NewRequest("DELETE", api.URL+"/jobs/"+queued.ID, nil)
+    if resp, err := http.DefaultClient.Do(req); err != nil {
+        t.Fatal(err)
+    } else {
+        resp.Body.Close()
+    }
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+        code, _ := submitJob(t, api.URL, job)
+        if code == http.StatusAccepted {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("job still rejected with %d after cancelling the queued one", code)
+        }
+        time.Sleep(20 * time.Millisecond)
+    }
+}
+
+func TestServerRejectsInvalidJobs(t *testing.T) {
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    api := httptest.NewServer(NewServer("secret", 1, 0, 10).Handler(ctx))
+    defer api.Close()
+
+    tests := []struct {
+        name  string
+        token string
+        body  string
+        want  int
+    }{
+        {"missing token", "", `{"targets": ["example.com"]}`, http.StatusUnauthorized},
+        {"invalid JSON", "secret", `{"targets": `, http.StatusBadRequest},
+        {"no targets", "secret", `{"targets": []}`, http.StatusBadRequest},
+        {"invalid timeout", "secret", `{"targets": ["example.com"], "config": {"timeout": "soon"}}`, http.StatusBadRequest},
+        {"invalid probe", "secret", `{"targets": ["example.com"], "config": {"probe": "ftp"}}`, http.StatusBadRequest},
+    }
+    for _, tt := range tests {
+        req, _ := http.NewRequest("POST", api.URL+"/jobs", bytes.NewBufferString(tt.body))
+        if tt.token != "" {
+            req.Header.Set("Authorization", "Bearer "+tt.token)
+        }
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            t.Fatal(err)
+        }
+        resp.Body.Close()
+        if resp.StatusCode != tt.want {
+            t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.want)
+        }
+    }
+}
+
+func TestServerJobConfigProfiles(t *testing.T) {
+    s := NewServer("", 1, 0, 10)
+    tests := []struct {
+        name        string
+        job         JobConfig
+        wantRate    float64
+        wantTimeout time.Duration
+    }{
+        {"fast", JobConfig{Fast: true}, DEFAULT_RATE * 2, time.Second},
+        {"verify", JobConfig{Verify: true}, DEFAULT_RATE, 10 * time.Second},
+        {"explicit values win", JobConfig{Fast: true, Rate: 7, Timeout: "3s"}, 7, 3 * time.Second},
+    }
+    for _, tt := range tests {
+        config, err := s.jobConfig(tt.job)
+        if err != nil {
+            t.Fatalf("%s: %v", tt.name, err)
+        }
+        if config.Rate != tt.wantRate || config.Timeout != tt.wantTimeout {
+            t.Errorf("%s: rate %v timeout %v, want %v and %v", tt.name, config.Rate, config.Timeout, tt.wantRate, tt.wantTimeout)
+        }
+    }
+}
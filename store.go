@@ -0,0 +1,206 @@
+package main
+
+import (
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "sync/atomic"
+    "time"
+
+    _ "modernc.org/sqlite" // Pure-Go SQLite driver, keeps CGO_ENABLED=0 builds working
+)
+
+const STORE_BATCH_SIZE = 500 // Results written per transaction
+
+// Schema is created on first use; results keep the full JSON alongside the
+// columns most queries need
+const storeSchema = `
+CREATE TABLE IF NOT EXISTS scans (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    started_at  TEXT NOT NULL,
+    finished_at TEXT,
+    version     TEXT NOT NULL,
+    config      TEXT NOT NULL,
+    targets     INTEGER NOT NULL,
+    checked     INTEGER NOT NULL DEFAULT 0,
+    alive       INTEGER NOT NULL DEFAULT 0,
+    verified    INTEGER NOT NULL DEFAULT 0,
+    errors      INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS results (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    scan_id          INTEGER NOT NULL REFERENCES scans(id),
+    checked_at       TEXT NOT NULL,
+    url              TEXT NOT NULL,
+    status_code      INTEGER NOT NULL,
+    content_length   INTEGER NOT NULL,
+    response_time_ms INTEGER NOT NULL,
+    title            TEXT,
+    server           TEXT,
+    redirect         TEXT,
+    error            TEXT,
+    alive            INTEGER NOT NULL,
+    verified         INTEGER NOT NULL,
+    data             TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_results_scan ON results(scan_id);
+CREATE INDEX IF NOT EXISTS idx_results_url ON results(url);
+`
+
+// Store persists scans and their results in a SQLite database
+type Store struct {
+    db      *sql.DB
+    scanID  int64
+    tx      *sql.Tx
+    insert  *sql.Stmt
+    pending int
+}
+
+// OpenStore opens (or creates) the database and its schema
+func OpenStore(path string) (*Store, error) {
+    db, err := sql.Open("sqlite", path)
+    if err != nil {
+        return nil, fmt.Errorf("error opening database: %v", err)
+    }
+    // SQLite allows one writer; a single connection avoids lock errors
+    db.SetMaxOpenConns(1)
+
+    if _, err := db.Exec("PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000;"); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("error opening database: %v", err)
+    }
+    if _, err := db.Exec(storeSchema); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("error creating database schema: %v", err)
+    }
+    return &Store{db: db}, nil
+}
+
+// StartScan records a new scan with a snapshot of its configuration
+func (s *Store) StartScan(config *Config, targets int64) (int64, error) {
+    snapshot, err := json.Marshal(config)
+    if err != nil {
+        return 0, err
+    }
+
+    res, err := s.db.Exec(`INSERT INTO scans (started_at, version, config, targets) VALUES (?, ?, ?, ?)`,
+        time.Now().UTC().Format(time.RFC3339Nano), VERSION, string(snapshot), targets)
+    if err != nil {
+        return 0, fmt.Errorf("error recording scan: %v", err)
+    }
+    s.scanID, err = res.LastInsertId()
+    return s.scanID, err
+}
+
+// Save queues a result for the current scan, committing in batches
+func (s *Store) Save(result *Result) error {
+    if s.tx == nil {
+        tx, err := s.db.Begin()
+        if err != nil {
+            return err
+        }
+        insert, err := tx.Prepare(`INSERT INTO results (scan_id, checked_at, url, status_code, content_length,
+            response_time_ms, title, server, redirect, error, alive, verified, data)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+        if err != nil {
+            tx.Rollback()
+            return err
+        }
+        s.tx, s.insert = tx, insert
+    }
+
+    data, err := json.Marshal(result)
+    if err != nil {
+        return err
+    }
+    _, err = s.insert.Exec(s.scanID, time.Now().UTC().Format(time.RFC3339Nano), result.URL, result.Status,
+        result.Length, result.ResponseTime.Milliseconds(), result.Title, result.Server, result.Redirect,
+        result.Error, result.Alive, result.Verified, string(data))
+    if err != nil {
+        return err
+    }
+
+    s.pending++
+    if s.pending >= STORE_BATCH_SIZE {
+        return s.commit()
+    }
+    return nil
+}
+
+// commit writes the pending batch
+func (s *Store) commit() error {
+    if s.tx == nil {
+        return nil
+    }
+    s.insert.Close()
+    err := s.tx.Commit()
+    s.tx, s.insert, s.pending = nil, nil, 0
+    return err
+}
+
+// FinishScan commits remaining results and records the final stats; an
+// interrupted scan keeps finished_at NULL so it is never used as a baseline
+func (s *Store) FinishScan(stats *Stats, interrupted bool) error {
+    if err := s.commit(); err != nil {
+        return fmt.Errorf("error saving results: %v", err)
+    }
+    var finishedAt interface{}
+    if !interrupted {
+        finishedAt = time.Now().UTC().Format(time.RFC3339Nano)
+    }
+    _, err := s.db.Exec(`UPDATE scans SET finished_at = ?, checked = ?, alive = ?, verified = ?, errors = ? WHERE id = ?`,
+        finishedAt,
+        atomic.LoadUint64(&stats.checked),
+        atomic.LoadUint64(&stats.alive),
+        atomic.LoadUint64(&stats.verified),
+        atomic.LoadUint64(&stats.errors),
+        s.scanID)
+    if err != nil {
+        return fmt.Errorf("error recording scan: %v", err)
+    }
+    return nil
+}
+
+// LoadScan returns the stored results of a previous scan
+func (s *Store) LoadScan(scanID int64) ([]*Result, error) {
+    rows, err := s.db.Query(`SELECT data FROM results WHERE scan_id = ? ORDER BY id`, scanID)
+    if err != nil {
+        return nil, fmt.Errorf("error loading scan %d: %v", scanID, err)
+    }
+    defer rows.Close()
+
+    var results []*Result
+    for rows.Next() {
+        var data string
+        if err := rows.Scan(&data); err != nil {
+            return nil, err
+        }
+        result := &Result{}
+        if err := json.Unmarshal([]byte(data), result); err != nil {
+            return nil, fmt.Errorf("error loading scan %d: %v", scanID, err)
+        }
+        results = append(results, result)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    if len(results) == 0 {
+        return nil, fmt.Errorf("scan %d has no stored results", scanID)
+    }
+    return results, nil
+}
+
+// LatestScanID returns the most recent finished scan, or 0 when there is none
+func (s *Store) LatestScanID() (int64, error) {
+    var scanID sql.NullInt64
+    err := s.db.QueryRow(`SELECT MAX(id) FROM scans WHERE finished_at IS NOT NULL`).Scan(&scanID)
+    if err != nil {
+        return 0, fmt.Errorf("error reading scan history: %v", err)
+    }
+    return scanID.Int64, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+    return s.db.Close()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestStoreLatestScanSkipsInterrupted(t *testing.T) {
+    store, err := OpenStore(filepath.Join(t.TempDir(), "scans.sqlite"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer store.Close()
+
+    scan := func(interrupted bool, urls ...string) int64 {
+        stats := &Stats{started: time.Now(), totalUrls: int64(len(urls))}
+        scanID, err := store.StartScan(defaultConfig(), stats.totalUrls)
+        if err != nil {
+            t.Fatal(err)
+        }
+        for _, u := range urls {
+            if err := store.Save(&Result{URL: u, Status: 200, Alive: true}); err != nil {
+                t.Fatal(err)
+            }
+            stats.checked++
+        }
+        if err := store.FinishScan(stats, interrupted); err != nil {
+            t.Fatal(err)
+        }
+        return scanID
+    }
+
+    // Each scan becomes the baseline only if it completed
+    var want int64
+    for i, interrupted := range []bool{true, false, true, false} {
+        scanID := scan(interrupted, "https://a.example.com", "https://b.example.com")
+        if !interrupted {
+            want = scanID
+        }
+        latest, err := store.LatestScanID()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if latest != want {
+            t.Errorf("scan %d (interrupted %t): latest = %d, want %d", i+1, interrupted, latest, want)
+        }
+
+        // Interrupted scans keep their results for explicit -diff <id> use
+        results, err := store.LoadScan(scanID)
+        if err != nil || len(results) != 2 {
+            t.Errorf("scan %d: loaded %d results, %v; want 2", i+1, len(results), err)
+        }
+    }
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "reflect"
+    "strings"
+    "time"
+)
+
+// Table output formats
+const (
+    TableCSV      = "csv"
+    TableTSV      = "tsv"
+    TableMarkdown = "markdown"
+)
+
+// Columns used when -columns is not given
+var defaultColumns = []string{"url", "status_code", "content_length", "title", "server", "redirect", "verified"}
+
+// ResultFormatter writes results in a format that keeps state between rows
+type ResultFormatter interface {
+    Format(result *Result) error
+    Close() error
+}
+
+// TableFormatter writes results as CSV, TSV or Markdown table rows
+type TableFormatter struct {
+    format  string
+    columns []string
+    w       io.Writer
+    csv     *csv.Writer
+}
+
+// NewTableFormatter validates the columns and writes the header row
+func NewTableFormatter(w io.Writer, format string, columns []string) (*TableFormatter, error) {
+    if len(columns) == 0 {
+        columns = defaultColumns
+    }
+    known := resultFieldNames()
+    for _, column := range columns {
+        if !known[column] {
+            return nil, fmt.Errorf("unknown column %q (use JSON field names such as url, status_code, title)", column)
+        }
+    }
+
+    tf := &TableFormatter{format: format, columns: columns, w: w}
+    switch format {
+    case TableCSV:
+        tf.csv = csv.NewWriter(w)
+    case TableTSV, TableMarkdown:
+    default:
+        return nil, fmt.Errorf("unknown table format: %s", format)
+    }
+
+    if err := tf.writeRow(columns); err != nil {
+        return nil, err
+    }
+    if format == TableMarkdown {
+        separator := make([]string, len(columns))
+        for i := range separator {
+            separator[i] = "---"
+        }
+        if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(separator, " | ")); err != nil {
+            return nil, err
+        }
+    }
+    return tf, nil
+}
+
+// Format writes one result as a table row
+func (tf *TableFormatter) Format(result *Result) error {
+    fields := resultFields(result)
+    row := make([]string, len(tf.columns))
+    for i, column := range tf.columns {
+        row[i] = fields[column]
+    }
+    return tf.writeRow(row)
+}
+
+// Close flushes any buffered output
+func (tf *TableFormatter) Close() error {
+    if tf.csv != nil {
+        tf.csv.Flush()
+        return tf.csv.Error()
+    }
+    return nil
+}
+
+// writeRow escapes and writes cells for the configured format
+func (tf *TableFormatter) writeRow(cells []string) error {
+    switch tf.format {
+    case TableCSV:
+        escaped := make([]string, len(cells))
+        for i, cell := range cells {
+            escaped[i] = spreadsheetSafe(cell)
+        }
+        if err := tf.csv.Write(escaped); err != nil {
+            return err
+        }
+        // Flush per row so results stream through pipelines
+        tf.csv.Flush()
+        return tf.csv.Error()
+    case TableTSV:
+        escaped := make([]string, len(cells))
+        for i, cell := range cells {
+            escaped[i] = spreadsheetSafe(strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(cell))
+        }
+        _, err := fmt.Fprintln(tf.w, strings.Join(escaped, "\t"))
+        return err
+    default:
+        escaped := make([]string, len(cells))
+        for i, cell := range cells {
+            escaped[i] = strings.NewReplacer("\\", "\\\\", "|", "\\|", "\n", " ", "\r", " ").Replace(cell)
+        }
+        _, err := fmt.Fprintf(tf.w, "| %s |\n", strings.Join(escaped, " | "))
+        return err
+    }
+}
+
+// spreadsheetSafe prefixes cells that a spreadsheet would evaluate as a
+// formula, since titles and headers come from the scanned targets
+func spreadsheetSafe(cell string) string {
+    if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+        return "'" + cell
+    }
+    return cell
+}
+
+// resultFieldNames returns the JSON names of all Result fields
+func resultFieldNames() map[string]bool {
+    names := make(map[string]bool)
+    t := reflect.TypeOf(Result{})
+    for i := 0; i < t.NumField(); i++ {
+        if name := jsonFieldName(t.Field(i)); name != "" {
+            names[name] = true
+        }
+    }
+    return names
+}
+
+// resultFields renders every Result field as text, keyed by JSON name
+func resultFields(result *Result) map[string]string {
+    fields := make(map[string]string)
+    v := reflect.ValueOf(result).Elem()
+    t := v.Type()
+    for i := 0; i < t.NumField(); i++ {
+        if name := jsonFieldName(t.Field(i)); name != "" {
+            fields[name] = formatFieldValue(v.Field(i))
+        }
+    }
+    return fields
+}
+
+// jsonFieldName returns the JSON name from a struct field's tag
+func jsonFieldName(field reflect.StructField) string {
+    name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+    if name == "-" {
+        return ""
+    }
+    return name
+}
+
+// formatFieldValue renders scalars directly and nested values as JSON
+func formatFieldValue(v reflect.Value) string {
+    if d, ok := v.Interface().(time.Duration); ok {
+        return fmt.Sprintf("%d", d.Milliseconds())
+    }
+
+    switch v.Kind() {
+    case reflect.String:
+        return v.String()
+    case reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint64, reflect.Float64:
+        return fmt.Sprint(v.Interface())
+    case reflect.Slice:
+        if v.Len() == 0 {
+            return ""
+        }
+        if v.Type().Elem().Kind() == reflect.String {
+            items := make([]string, v.Len())
+            for i := range items {
+                items[i] = v.Index(i).String()
+            }
+            return strings.Join(items, ",")
+        }
+    case reflect.Ptr, reflect.Map:
+        if v.IsNil() {
+            return ""
+        }
+    }
+
+    data, err := json.Marshal(v.Interface())
+    if err != nil {
+        return ""
+    }
+    return string(data)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+    "bytes"
+    "testing"
+)
+
+func TestTableFormatterEscaping(t *testing.T) {
+    result := &Result{
+        URL:    "https://example.com",
+        Status: 200,
+        Title:  "=HYPERLINK(\"http://evil.example\",\"click\")",
+        Server: `nginx | edge\`,
+    }
+    tests := []struct {
+        format string
+        want   string
+    }{
+        {TableCSV, "url,status_code,title,server\n" +
+            `https://example.com,200,"'=HYPERLINK(""http://evil.example"",""click"")",nginx | edge\` + "\n"},
+        {TableTSV, "url\tstatus_code\ttitle\tserver\n" +
+            "https://example.com\t200\t'=HYPERLINK(\"http://evil.example\",\"click\")\tnginx | edge\\\n"},
+        {TableMarkdown, "| url | status_code | title | server |\n| --- | --- | --- | --- |\n" +
+            `| https://example.com | 200 | =HYPERLINK("http://evil.example","click") | nginx \| edge\\ |` + "\n"},
+    }
+    for _, tt := range tests {
+        var buf bytes.Buffer
+        tf, err := NewTableFormatter(&buf, tt.format, []string{"url", "status_code", "title", "server"})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if err := tf.Format(result); err != nil {
+            t.Fatal(err)
+        }
+        if err := tf.Close(); err != nil {
+            t.Fatal(err)
+        }
+        if buf.String() != tt.want {
+            t.Errorf("%s:\ngot  %q\nwant %q", tt.format, buf.String(), tt.want)
+        }
+    }
+}
+
+func TestSpreadsheetSafe(t *testing.T) {
+    tests := []struct {
+        cell string
+        want string
+    }{
+        {"=1+1", "'=1+1"},
+        {"+cmd", "'+cmd"},
+        {"-2+3", "'-2+3"},
+        {"@SUM(A1)", "'@SUM(A1)"},
+        {"\tx", "'\tx"},
+        {"nginx", "nginx"},
+        {"a=b", "a=b"},
+        {"", ""},
+    }
+    for _, tt := range tests {
+        if got := spreadsheetSafe(tt.cell); got != tt.want {
+            t.Errorf("spreadsheetSafe(%q) = %q, want %q", tt.cell, got, tt.want)
+        }
+    }
+}
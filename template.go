@@ -0,0 +1,139 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "text/template"
+
+    "github.com/fatih/color"
+)
+
+// Built-in output templates, selected by -json, -silent/-clean or the default
+const (
+    TemplateJSON  = `{{json .}}`
+    TemplateClean = `{{if eq .Diff "gone"}}{{if config.ShowFailed}}{{.URL}} [GONE]{{end}}` +
+        `{{else if .Alive}}{{.URL}}{{else if config.ShowFailed}}{{.URL}} [FAILED]{{end}}`
+
+    TemplateDetailed = `{{if eq .Diff "gone"}}{{.URL}} [GONE]{{if .Error}} [{{.Error}}]{{end}}` +
+        `{{else if .Alive}}{{.URL}}` +
+        `{{if eq .Diff "new"}} [NEW]{{end}}` +
+        `{{if and config.ExtractTitle .Title}} [{{.Title}}]{{end}}` +
+        `{{if ne .Status 200}} [{{.Status}}]{{end}}` +
+        `{{if beyondHTTP11 .Protocols}} [{{join "," .Protocols}}]{{end}}` +
+        `{{if and .H2C .H2C.Upgrade}} [H2C UPGRADE]{{end}}` +
+        `{{if .SchemeMismatch}} [MISMATCH: {{.SchemeMismatch}}]{{end}}` +
+        `{{if .Verified}} [VERIFIED]{{end}}` +
+        `{{with .Schemes}}{{if .MissingUpgrade}} [NO HTTPS REDIRECT]{{end}}{{if and .HTTPS.Status (not .HSTS)}} [NO HSTS]{{end}}{{end}}` +
+        `{{if .Redirect}} -> {{.Redirect}}{{end}}` +
+        `{{if .Changes}} [CHANGED: {{join ", " .Changes}}]{{end}}` +
+        `{{else if .Service}}{{hostport .URL}} [SERVICE: {{.Service}}] [{{.Banner}}]` +
+        `{{else if config.ShowFailed}}{{.URL}} [FAILED: {{.Error}}]{{end}}`
+)
+
+// Colour names accepted by the color template helper
+var templateColors = map[string]color.Attribute{
+    "black":   color.FgBlack,
+    "red":     color.FgRed,
+    "green":   color.FgGreen,
+    "yellow":  color.FgYellow,
+    "blue":    color.FgBlue,
+    "magenta": color.FgMagenta,
+    "cyan":    color.FgCyan,
+    "white":   color.FgWhite,
+    "gray":    color.FgHiBlack,
+    "bold":    color.Bold,
+}
+
+// TemplateFormatter renders each result with a text/template
+type TemplateFormatter struct {
+    tmpl *template.Template
+    w    io.Writer
+    buf  bytes.Buffer
+}
+
+// NewTemplateFormatter parses a result template with the helper functions
+func NewTemplateFormatter(w io.Writer, text string, config *Config) (*TemplateFormatter, error) {
+    tmpl, err := template.New("output").Funcs(templateFuncs(config)).Parse(text)
+    if err != nil {
+        return nil, fmt.Errorf("invalid output template: %v", err)
+    }
+    return &TemplateFormatter{tmpl: tmpl, w: w}, nil
+}
+
+// loadTemplate returns the -format text, the -format-file contents or the
+// built-in template for the selected output mode
+func loadTemplate(format, formatFile string, config *Config) (string, error) {
+    switch {
+    case format != "":
+        return format, nil
+    case formatFile != "":
+        data, err := os.ReadFile(formatFile)
+        if err != nil {
+            return "", fmt.Errorf("error reading template file: %v", err)
+        }
+        return strings.TrimRight(string(data), "\r\n"), nil
+    case config.JSONOutput:
+        return TemplateJSON, nil
+    case config.Silent || config.CleanOutput:
+        return TemplateClean, nil
+    default:
+        return TemplateDetailed, nil
+    }
+}
+
+// Format renders one result; results rendering to nothing are skipped
+func (tf *TemplateFormatter) Format(result *Result) error {
+    tf.buf.Reset()
+    if err := tf.tmpl.Execute(&tf.buf, result); err != nil {
+        return err
+    }
+    if tf.buf.Len() == 0 {
+        return nil
+    }
+    if !bytes.HasSuffix(tf.buf.Bytes(), []byte("\n")) {
+        tf.buf.WriteByte('\n')
+    }
+    _, err := tf.w.Write(tf.buf.Bytes())
+    return err
+}
+
+// Close has nothing to flush, each result is written as it is rendered
+func (tf *TemplateFormatter) Close() error {
+    return nil
+}
+
+// templateFuncs returns the helpers available to output templates; list and
+// text arguments come last so they work in pipelines ({{.Title | pad 30}})
+func templateFuncs(config *Config) template.FuncMap {
+    return template.FuncMap{
+        "config":   func() *Config { return config },
+        "hostport": bannerAddress,
+        "pad": func(width int, value interface{}) string {
+            return fmt.Sprintf("%-*v", width, value)
+        },
+        "padleft": func(width int, value interface{}) string {
+            return fmt.Sprintf("%*v", width, value)
+        },
+        "color": func(name string, value interface{}) (string, error) {
+            attr, ok := templateColors[strings.ToLower(name)]
+            if !ok {
+                return "", fmt.Errorf("unknown colour %q", name)
+            }
+            return color.New(attr).Sprint(value), nil
+        },
+        "join": func(sep string, items []string) string {
+            return strings.Join(items, sep)
+        },
+        "json": func(value interface{}) (string, error) {
+            data, err := json.Marshal(value)
+            return string(data), err
+        },
+        "beyondHTTP11": func(protocols []string) bool {
+            return len(protocols) > 1 || (len(protocols) == 1 && protocols[0] != ProtoHTTP11)
+        },
+    }
+}
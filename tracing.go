@@ -0,0 +1,94 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/http/httptrace"
+    "os"
+    "strings"
+
+    "go.opentelemetry.io/contrib/instrumentation/net/http/httptrace/otelhttptrace"
+    "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+    "go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+    "go.opentelemetry.io/otel/sdk/resource"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// Trace exporters selected by -trace
+const (
+    TraceOTLP   = "otlp"   // OTLP over HTTP to a collector
+    TraceStdout = "stdout" // JSON spans on stderr, keeping stdout for results
+)
+
+// tracer follows the global provider, a no-op until setupTracing runs
+var tracer = otel.Tracer("github.com/Acorzo1983/AliveHunter")
+
+// setupTracing installs the global tracer provider; the returned function
+// flushes pending spans
+func setupTracing(ctx context.Context, exporter, endpoint string, sampleRatio float64) (func(context.Context) error, error) {
+    var spanExporter sdktrace.SpanExporter
+    var err error
+    switch exporter {
+    case TraceStdout:
+        spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+    case TraceOTLP:
+        // Without an endpoint the OTEL_EXPORTER_OTLP_* variables apply
+        var opts []otlptracehttp.Option
+        if strings.Contains(endpoint, "://") {
+            opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+        } else if endpoint != "" {
+            opts = append(opts, otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure())
+        }
+        spanExporter, err = otlptracehttp.New(ctx, opts...)
+    default:
+        return nil, fmt.Errorf("unknown -trace exporter %q (use otlp or stdout)", exporter)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("error creating trace exporter: %v", err)
+    }
+
+    provider := sdktrace.NewTracerProvider(
+        sdktrace.WithBatcher(spanExporter),
+        sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+        sdktrace.WithResource(resource.NewSchemaless(
+            attribute.String("service.name", "alivehunter"),
+            attribute.String("service.version", VERSION),
+        )),
+    )
+    otel.SetTracerProvider(provider)
+    return provider.Shutdown, nil
+}
+
+// tracedTransport wraps a transport so every request gets a client span with
+// DNS, connect and TLS timings
+func tracedTransport(transport http.RoundTripper) http.RoundTripper {
+    return otelhttp.NewTransport(transport,
+        otelhttp.WithClientTrace(func(ctx context.Context) *httptrace.ClientTrace {
+            return otelhttptrace.NewClientTrace(ctx)
+        }),
+    )
+}
+
+// endProbeSpan records the probe outcome on its span and ends it
+func endProbeSpan(span trace.Span, result *Result, err error) {
+    if err != nil {
+        span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+    } else if result != nil {
+        span.SetAttributes(
+            attribute.Int("http.response.status_code", result.Status),
+            attribute.Bool("alivehunter.alive", result.Alive),
+            attribute.Bool("alivehunter.verified", result.Verified),
+        )
+        if result.Error != "" {
+            span.SetAttributes(attribute.String("alivehunter.error", result.Error))
+        }
+    }
+    span.End()
+}
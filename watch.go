@@ -0,0 +1,175 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
+
+    "github.com/robfig/cron/v3"
+)
+
+const DEFAULT_WATCH_INTERVAL = 1 * time.Hour // Time between watch mode scans
+
+// Watcher re-scans the targets on a schedule and outputs the changes between
+// consecutive scans
+type Watcher struct {
+    config      *Config
+    client      *AliveHTTPClient
+    formatter   ResultFormatter
+    notifier    *Notifier                 // Also in formatter, sent a summary after each scan
+    metrics     *Metrics
+    store       *Store
+    differ      *Differ                   // Baseline for the next scan
+    loadTargets func() ([]Target, error) // Re-reads the input on SIGHUP
+    targets     []Target
+}
+
+// watchSchedule builds the scan schedule: a cron expression when given,
+// otherwise a fixed delay between the end of one scan and the next
+func watchSchedule(interval time.Duration, cronExpr string) (cron.Schedule, error) {
+    if cronExpr != "" {
+        schedule, err := cron.ParseStandard(cronExpr)
+        if err != nil {
+            return nil, fmt.Errorf("invalid -cron expression: %v", err)
+        }
+        return schedule, nil
+    }
+    if interval < time.Second {
+        return nil, fmt.Errorf("-interval must be at least 1s")
+    }
+    return cron.Every(interval), nil
+}
+
+// Run scans until the context is cancelled; SIGHUP reloads the input
+func (w *Watcher) Run(ctx context.Context, interval time.Duration, cronExpr string) error {
+    schedule, err := watchSchedule(interval, cronExpr)
+    if err != nil {
+        return err
+    }
+
+    // Without a -diff baseline, continue from the last stored scan
+    if w.differ == nil && w.store != nil {
+        scanID, err := w.store.LatestScanID()
+        if err != nil {
+            return err
+        }
+        if scanID != 0 {
+            if w.differ, err = LoadBaseline(fmt.Sprint(scanID), w.store); err != nil {
+                return err
+            }
+            w.logf("Watch: continuing from stored scan %d", scanID)
+        }
+    }
+    // First scan with no baseline reports every live host as new
+    if w.differ == nil {
+        w.differ = newDiffer(nil)
+    }
+
+    reload := make(chan os.Signal, 1)
+    signal.Notify(reload, syscall.SIGHUP)
+    defer signal.Stop(reload)
+
+    for {
+        w.scan(ctx)
+        if ctx.Err() != nil {
+            return nil
+        }
+
+        next := schedule.Next(time.Now())
+        w.logf("Watch: next scan at %s", next.Format(time.RFC3339))
+
+    wait:
+        for {
+            timer := time.NewTimer(time.Until(next))
+            select {
+            case <-ctx.Done():
+                timer.Stop()
+                return nil
+            case <-reload:
+                timer.Stop()
+                w.reload()
+            case <-timer.C:
+                break wait
+            }
+        }
+    }
+}
+
+// scan runs one iteration, outputs the changes and keeps the results as the
+// baseline for the next iteration
+func (w *Watcher) scan(ctx context.Context) {
+    stats := &Stats{
+        started:   time.Now(),
+        totalUrls: int64(len(w.targets)),
+    }
+
+    var scanID int64
+    if w.store != nil {
+        var err error
+        if scanID, err = w.store.StartScan(w.config, stats.totalUrls); err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+        }
+    }
+
+    if w.metrics != nil {
+        w.metrics.ScanStarted(stats.totalUrls)
+    }
+    var results []*Result
+    runScan(ctx, w.targets, w.client, w.config, stats, func(result *Result) {
+        if w.metrics != nil {
+            w.metrics.Observe(result)
+        }
+        w.differ.Apply(result)
+        if w.store != nil && scanID != 0 {
+            if err := w.store.Save(result); err != nil {
+                fmt.Fprintf(os.Stderr, "Error saving result: %v\n", err)
+            }
+        }
+        outputResult(result, w.config, w.formatter)
+        results = append(results, result)
+    })
+    if w.metrics != nil {
+        w.metrics.ScanFinished()
+    }
+
+    if w.store != nil && scanID != 0 {
+        if err := w.store.FinishScan(stats, ctx.Err() != nil); err != nil {
+            fmt.Fprintf(os.Stderr, "%v\n", err)
+        }
+    }
+
+    // An interrupted scan is incomplete and would report hosts as gone
+    if ctx.Err() == nil {
+        // Hosts dropped from the input since the last scan
+        for _, result := range w.differ.Missing() {
+            outputResult(result, w.config, w.formatter)
+        }
+        summary := stats.String() + " | " + w.differ.String()
+        w.logf("\r\033[KWatch: scan completed: %s", summary)
+        if w.notifier != nil {
+            w.notifier.ScanComplete(summary)
+        }
+        w.differ = newDiffer(results)
+    }
+}
+
+// reload re-reads the input, keeping the current targets if that fails
+func (w *Watcher) reload() {
+    w.logf("Watch: received SIGHUP, reloading input")
+    targets, err := w.loadTargets()
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Watch: reload failed, keeping %d targets: %v\n", len(w.targets), err)
+        return
+    }
+    w.targets = targets
+}
+
+// logf writes watch progress to stderr unless silent
+func (w *Watcher) logf(format string, args ...interface{}) {
+    if !w.config.Silent {
+        fmt.Fprintf(os.Stderr, format+"\n", args...)
+    }
+}